@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"path"
+	"time"
 )
 
 type BindConfig struct {
@@ -27,13 +28,68 @@ func (c *DataConfig) RecipeOriginalsPath() string {
 	return path.Join(c.RecipeImagesBase, "original")
 }
 
+type OIDCConfig struct {
+	IssuerURL     *string  `env:"ISSUER_URL"`
+	ClientID      string   `env:"CLIENT_ID"`
+	ClientSecret  string   `env:"CLIENT_SECRET"`
+	RedirectURL   string   `env:"REDIRECT_URL"`
+	Scopes        []string `env:"SCOPES" envSeparator:"," envDefault:"openid,profile"`
+	ProviderName  string   `env:"PROVIDER_NAME" envDefault:"OpenID Connect"`
+	AutoProvision bool     `env:"AUTO_PROVISION" envDefault:"false"`
+}
+
+func (c *OIDCConfig) IsEnabled() bool {
+	return c.IssuerURL != nil
+}
+
+// Limits protecting the authentication routes, a value of 0 disables a limit
+type AuthLimitsConfig struct {
+	IPPerMinute       uint          `env:"IP_PER_MINUTE" envDefault:"30"`
+	UsernamePerMinute uint          `env:"USERNAME_PER_MINUTE" envDefault:"10"`
+	LockoutThreshold  uint          `env:"LOCKOUT_THRESHOLD" envDefault:"5"`
+	LockoutBase       time.Duration `env:"LOCKOUT_BASE" envDefault:"30s"`
+	LockoutMax        time.Duration `env:"LOCKOUT_MAX" envDefault:"1h"`
+}
+
+// Settings for importing recipes from other sites
+type ImportConfig struct {
+	// allow fetching from loopback & private network addresses
+	AllowPrivateHosts bool          `env:"ALLOW_PRIVATE_HOSTS" envDefault:"false"`
+	Timeout           time.Duration `env:"TIMEOUT" envDefault:"15s"`
+	MaxArchiveSize    string        `env:"MAX_ARCHIVE_SIZE" envDefault:"50M"`
+}
+
+type RegistrationMode string
+
+const (
+	RegistrationOpen   RegistrationMode = "open"
+	RegistrationInvite RegistrationMode = "invite"
+	RegistrationClosed RegistrationMode = "closed"
+)
+
+func (m *RegistrationMode) UnmarshalText(text []byte) error {
+	switch mode := RegistrationMode(text); mode {
+	case RegistrationOpen, RegistrationInvite, RegistrationClosed:
+		*m = mode
+		return nil
+	default:
+		return fmt.Errorf("invalid registration mode '%s'", mode)
+	}
+}
+
 type AppConfig struct {
-	Bind                 BindConfig    `envPrefix:"BIND__"`
-	DB                   DBConfig      `envPrefix:"DB__"`
-	Data                 DataConfig    `envPrefix:"DATA__"`
-	JWTSecret            Base64Decoded `env:"JWT_SECRET,notEmpty"`
-	StaticPath           *string       `env:"STATIC_PATH"`
-	CORSOrigins          []string      `env:"CORS_ORIGINS" envSeparator:"," envDefault:"*"`
-	OptimizedImageSize   uint          `env:"OPTIMIZED_IMAGE_SIZE" envDefault:"2000"`
-	ImageUploadSizeLimit string        `env:"MAX_UPLOAD_SIZE" envDefault:"4M"`
+	Bind                 BindConfig       `envPrefix:"BIND__"`
+	DB                   DBConfig         `envPrefix:"DB__"`
+	Data                 DataConfig       `envPrefix:"DATA__"`
+	OIDC                 OIDCConfig       `envPrefix:"OIDC__"`
+	AuthLimits           AuthLimitsConfig `envPrefix:"AUTH_LIMITS__"`
+	Import               ImportConfig     `envPrefix:"IMPORT__"`
+	JWTSecret            Base64Decoded    `env:"JWT_SECRET,notEmpty"`
+	AccessTokenExpiry    time.Duration    `env:"ACCESS_TOKEN_EXPIRY" envDefault:"15m"`
+	RefreshTokenExpiry   time.Duration    `env:"REFRESH_TOKEN_EXPIRY" envDefault:"720h"`
+	StaticPath           *string          `env:"STATIC_PATH"`
+	CORSOrigins          []string         `env:"CORS_ORIGINS" envSeparator:"," envDefault:"*"`
+	OptimizedImageSize   uint             `env:"OPTIMIZED_IMAGE_SIZE" envDefault:"2000"`
+	ImageUploadSizeLimit string           `env:"MAX_UPLOAD_SIZE" envDefault:"4M"`
+	RegistrationMode     RegistrationMode `env:"REGISTRATION_MODE" envDefault:"open"`
 }
@@ -12,6 +12,9 @@ func (appConfig *AppConfig) ParseConfig() error {
 	if err := env.Parse(appConfig); err != nil {
 		return err
 	}
+	if appConfig.OIDC.IsEnabled() && (appConfig.OIDC.ClientID == "" || appConfig.OIDC.RedirectURL == "") {
+		return errors.New("OIDC__CLIENT_ID and OIDC__REDIRECT_URL are required when OIDC is enabled")
+	}
 	return nil
 }
 
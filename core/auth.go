@@ -1,9 +1,15 @@
 package core
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
@@ -15,12 +21,17 @@ func GetAuthenticatedUserFromContext(ctx echo.Context) (AuthenticatedUser, error
 }
 
 // Create token for authentication
-func CreateAuthenticationToken(user AuthenticatedUser, secretKey []byte) (LoginToken, error) {
-	expiresAt := time.Now().Add(time.Hour * 72)
+func CreateAuthenticationToken(
+	user AuthenticatedUser,
+	secretKey []byte,
+	expiresIn time.Duration,
+) (LoginToken, error) {
+	expiresAt := time.Now().Add(expiresIn)
 	claims := &JWTClaims{
 		Username: user.Username,
 		IsAdmin:  user.IsAdmin,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        user.SessionID.String(),
 			Subject:   user.UserID.String(),
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 		},
@@ -36,3 +47,63 @@ func CreateAuthenticationToken(user AuthenticatedUser, secretKey []byte) (LoginT
 		Expiry: expiresAt,
 	}, nil
 }
+
+// Create a new random token, returning it along with the hash that should be stored
+func CreateOpaqueToken() (string, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	return token, HashOpaqueToken(token), nil
+}
+
+// Hash a token created by CreateOpaqueToken, so it can be looked up
+func HashOpaqueToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// Tokens proving the password was correct are signed with a different key,
+// so they can never be accepted as an access token
+func mfaSigningKey(secretKey []byte) []byte {
+	mac := hmac.New(sha256.New, secretKey)
+	mac.Write([]byte("mfa-token"))
+	return mac.Sum(nil)
+}
+
+// Create a short-lived token for completing a login with a second factor
+func CreateMFAToken(userID uuid.UUID, secretKey []byte, expiresIn time.Duration) (MFAToken, error) {
+	expiresAt := time.Now().Add(expiresIn)
+	claims := &jwt.RegisteredClaims{
+		Subject:   userID.String(),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	rawToken, err := token.SignedString(mfaSigningKey(secretKey))
+	if err != nil {
+		return MFAToken{}, err
+	}
+	return MFAToken{
+		Type:   "TOTP",
+		Token:  rawToken,
+		Expiry: expiresAt,
+	}, nil
+}
+
+// Get the user id from a token created by CreateMFAToken
+func ParseMFAToken(rawToken string, secretKey []byte) (uuid.UUID, error) {
+	var claims jwt.RegisteredClaims
+	if _, err := jwt.ParseWithClaims(
+		rawToken,
+		&claims,
+		func(t *jwt.Token) (any, error) {
+			return mfaSigningKey(secretKey), nil
+		},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	); err != nil {
+		return uuid.UUID{}, err
+	}
+	return uuid.Parse(claims.Subject)
+}
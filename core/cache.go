@@ -0,0 +1,97 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+type ttlCacheItem[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// A simple in-process cache, where items expire after a fixed time
+type TTLCache[K comparable, V any] struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	items     map[K]ttlCacheItem[V]
+	lastSweep time.Time
+	// the most items held at once, none when 0
+	maxItems int
+}
+
+func NewTTLCache[K comparable, V any](ttl time.Duration) *TTLCache[K, V] {
+	return NewBoundedTTLCache[K, V](ttl, 0)
+}
+
+// A cache holding at most maxItems, dropping the items closest to expiring to make room
+func NewBoundedTTLCache[K comparable, V any](ttl time.Duration, maxItems int) *TTLCache[K, V] {
+	return &TTLCache[K, V]{
+		ttl:       ttl,
+		items:     make(map[K]ttlCacheItem[V]),
+		lastSweep: time.Now(),
+		maxItems:  maxItems,
+	}
+}
+
+func (c *TTLCache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, ok := c.items[key]
+	if !ok || time.Now().After(item.expiresAt) {
+		var empty V
+		return empty, false
+	}
+	return item.value, true
+}
+
+func (c *TTLCache[K, V]) Set(key K, value V) {
+	c.SetUntil(key, value, time.Now().Add(c.ttl))
+}
+
+// Set an item that expires at the given time, or after the ttl if that is sooner
+func (c *TTLCache[K, V]) SetUntil(key K, value V, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if latest := now.Add(c.ttl); expiresAt.After(latest) {
+		expiresAt = latest
+	}
+	_, exists := c.items[key]
+	full := !exists && c.maxItems > 0 && len(c.items) >= c.maxItems
+	// remove expired items now and then, so the cache can't grow forever
+	if full || now.Sub(c.lastSweep) > c.ttl {
+		c.sweep(now)
+	}
+	if !exists && c.maxItems > 0 && len(c.items) >= c.maxItems {
+		c.evict()
+	}
+	c.items[key] = ttlCacheItem[V]{value: value, expiresAt: expiresAt}
+}
+
+func (c *TTLCache[K, V]) sweep(now time.Time) {
+	for k, item := range c.items {
+		if now.After(item.expiresAt) {
+			delete(c.items, k)
+		}
+	}
+	c.lastSweep = now
+}
+
+// Remove the item closest to expiring
+func (c *TTLCache[K, V]) evict() {
+	var soonestKey K
+	var soonest time.Time
+	for k, item := range c.items {
+		if soonest.IsZero() || item.expiresAt.Before(soonest) {
+			soonestKey, soonest = k, item.expiresAt
+		}
+	}
+	delete(c.items, soonestKey)
+}
+
+func (c *TTLCache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+}
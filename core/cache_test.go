@@ -0,0 +1,62 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLCache(t *testing.T) {
+	cache := NewTTLCache[string, int](time.Hour)
+	cache.Set("a", 1)
+	if value, ok := cache.Get("a"); !ok || value != 1 {
+		t.Errorf("Get(a) = %d, %v, want 1, true", value, ok)
+	}
+	cache.Delete("a")
+	if _, ok := cache.Get("a"); ok {
+		t.Error("expected a to be deleted")
+	}
+
+	// an expiry can be sooner than the ttl, but not later
+	cache.SetUntil("past", 1, time.Now().Add(-time.Second))
+	if _, ok := cache.Get("past"); ok {
+		t.Error("expected an expired item to be missing")
+	}
+	cache.SetUntil("later", 1, time.Now().Add(48*time.Hour))
+	if expiresAt := cache.items["later"].expiresAt; expiresAt.After(time.Now().Add(time.Hour)) {
+		t.Errorf("expires at %s, want within the ttl", expiresAt)
+	}
+}
+
+func TestBoundedTTLCache(t *testing.T) {
+	cache := NewBoundedTTLCache[string, int](time.Hour, 3)
+	cache.SetUntil("soonest", 1, time.Now().Add(time.Minute))
+	cache.Set("b", 2)
+	cache.Set("c", 3)
+	// updating an item doesn't make room
+	cache.Set("b", 4)
+	if len(cache.items) != 3 {
+		t.Fatalf("items = %d, want 3", len(cache.items))
+	}
+
+	cache.Set("d", 5)
+	if len(cache.items) != 3 {
+		t.Errorf("items = %d, want 3", len(cache.items))
+	}
+	if _, ok := cache.Get("soonest"); ok {
+		t.Error("expected the item closest to expiring to make room")
+	}
+	for _, key := range []string{"b", "c", "d"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("expected %s to be kept", key)
+		}
+	}
+
+	// expired items make room before anything is evicted
+	cache.SetUntil("b", 2, time.Now().Add(-time.Second))
+	cache.Set("e", 6)
+	for _, key := range []string{"c", "d", "e"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("expected %s to be kept", key)
+		}
+	}
+}
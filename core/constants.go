@@ -2,3 +2,5 @@ package core
 
 const APIVersionMajor uint = 0
 const APIVersionMinor uint = 1
+
+const AppName = "My Cooking Codex"
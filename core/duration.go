@@ -0,0 +1,111 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var iso8601DurationRegex = regexp.MustCompile(
+	`^P(?:(\d+(?:\.\d+)?)W)?(?:(\d+(?:\.\d+)?)D)?(?:T(?:(\d+(?:\.\d+)?)H)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)S)?)?$`,
+)
+
+// Parse an ISO-8601 duration, such as "PT1H30M".
+// Years and months are not supported, as they have no fixed length
+func ParseISO8601Duration(value string) (time.Duration, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	match := iso8601DurationRegex.FindStringSubmatch(value)
+	if match == nil || value == "P" || strings.HasSuffix(value, "T") {
+		return 0, fmt.Errorf("invalid ISO-8601 duration '%s'", value)
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var duration time.Duration
+	for i, unit := range units {
+		if match[i+1] == "" {
+			continue
+		}
+		amount, err := strconv.ParseFloat(match[i+1], 64)
+		if err != nil {
+			return 0, err
+		}
+		duration += time.Duration(amount * float64(unit))
+	}
+	return duration, nil
+}
+
+// Format a duration as ISO-8601, such as "PT1H30M".
+// Days are not used, so a duration is always written in hours and below
+func FormatISO8601Duration(duration time.Duration) string {
+	duration = duration.Round(time.Second)
+	if duration <= 0 {
+		return "PT0S"
+	}
+	hours := duration / time.Hour
+	minutes := duration % time.Hour / time.Minute
+	seconds := duration % time.Minute / time.Second
+
+	var builder strings.Builder
+	builder.WriteString("PT")
+	if hours != 0 {
+		fmt.Fprintf(&builder, "%dH", hours)
+	}
+	if minutes != 0 {
+		fmt.Fprintf(&builder, "%dM", minutes)
+	}
+	if seconds != 0 {
+		fmt.Fprintf(&builder, "%dS", seconds)
+	}
+	return builder.String()
+}
+
+var textDurationRegex = regexp.MustCompile(
+	`(?i)\b(\d+(?:[.,]\d+)?)(?:\s*(?:-|–|to)\s*\d+(?:[.,]\d+)?)?\s*(hours?|hrs?|minutes?|mins?|seconds?|secs?)\b`,
+)
+
+// What may come between the parts of one duration, such as "1 hour and 30 minutes"
+var durationJoinRegex = regexp.MustCompile(`(?i)^\s*(?:,|and)?\s*$`)
+
+// A duration found in text, along with the text it was found in
+type TextDuration struct {
+	Text     string
+	Duration time.Duration
+}
+
+func durationUnit(unit string) time.Duration {
+	switch strings.ToLower(unit)[0] {
+	case 'h':
+		return time.Hour
+	case 'm':
+		return time.Minute
+	}
+	return time.Second
+}
+
+// Find durations written in text, such as "bake for 25 minutes".
+// Ranges like "25-30 minutes" use the shorter time
+func FindDurations(text string) []TextDuration {
+	var durations []TextDuration
+	var start, end int
+	var previousUnit time.Duration
+	for _, match := range textDurationRegex.FindAllStringSubmatchIndex(text, -1) {
+		amount, err := strconv.ParseFloat(strings.Replace(text[match[2]:match[3]], ",", ".", 1), 64)
+		if err != nil || amount <= 0 {
+			continue
+		}
+		unit := durationUnit(text[match[4]:match[5]])
+		duration := time.Duration(amount * float64(unit))
+
+		// smaller units straight after a larger one are part of the same duration
+		if last := len(durations) - 1; last >= 0 && unit < previousUnit && durationJoinRegex.MatchString(text[end:match[0]]) {
+			durations[last].Duration += duration
+			durations[last].Text = text[start:match[1]]
+		} else {
+			start = match[0]
+			durations = append(durations, TextDuration{Text: text[start:match[1]], Duration: duration})
+		}
+		end, previousUnit = match[1], unit
+	}
+	return durations
+}
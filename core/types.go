@@ -2,6 +2,7 @@ package core
 
 import (
 	"encoding/json"
+	"net/http"
 	"strings"
 	"time"
 
@@ -37,10 +38,34 @@ func (su *SelectedUpdate[T]) FieldsAsString() []string {
 	return fields
 }
 
+type TokenScope string
+
+const (
+	TokenScopeRead      TokenScope = "read"
+	TokenScopeReadWrite TokenScope = "read-write"
+)
+
 type AuthenticatedUser struct {
-	UserID   uuid.UUID `json:"userId"`
-	Username string    `json:"username"`
-	IsAdmin  bool      `json:"isAdmin"`
+	UserID    uuid.UUID `json:"userId"`
+	Username  string    `json:"username"`
+	IsAdmin   bool      `json:"isAdmin"`
+	SessionID uuid.UUID `json:"-"`
+	// set when authenticated with a personal access token instead of a session
+	APITokenID *uuid.UUID `json:"-"`
+	Scope      TokenScope `json:"-"`
+}
+
+// Whether the user's token scope permits the given HTTP method
+func (u *AuthenticatedUser) CanUseMethod(method string) bool {
+	if u.APITokenID == nil || u.Scope == TokenScopeReadWrite {
+		return true
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
 }
 
 type JWTClaims struct {
@@ -50,18 +75,33 @@ type JWTClaims struct {
 }
 
 func (c *JWTClaims) ToAuthenticatedUser() (AuthenticatedUser, error) {
-	if userID, err := uuid.Parse(c.Subject); err != nil {
+	userID, err := uuid.Parse(c.Subject)
+	if err != nil {
+		return AuthenticatedUser{}, err
+	}
+	sessionID, err := uuid.Parse(c.ID)
+	if err != nil {
 		return AuthenticatedUser{}, err
-	} else {
-		return AuthenticatedUser{
-			UserID:   userID,
-			Username: c.Username,
-			IsAdmin:  c.IsAdmin,
-		}, nil
 	}
+	return AuthenticatedUser{
+		UserID:    userID,
+		Username:  c.Username,
+		IsAdmin:   c.IsAdmin,
+		SessionID: sessionID,
+		Scope:     TokenScopeReadWrite,
+	}, nil
 }
 
 type LoginToken struct {
+	Type          string    `json:"type"`
+	Token         string    `json:"token"`
+	Expiry        time.Time `json:"expiry"`
+	RefreshToken  string    `json:"refreshToken"`
+	RefreshExpiry time.Time `json:"refreshExpiry"`
+}
+
+// Returned instead of a LoginToken when a second factor is needed
+type MFAToken struct {
 	Type   string    `json:"type"`
 	Token  string    `json:"token"`
 	Expiry time.Time `json:"expiry"`
@@ -72,6 +112,16 @@ type CreateLogin struct {
 	Password string `json:"password" validate:"required"`
 }
 
+type CreateTOTPLogin struct {
+	Token        string `json:"token" validate:"required"`
+	Code         string `json:"code" validate:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recoveryCode" validate:"required_without=Code"`
+}
+
+type RefreshLogin struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
 type PaginationParams struct {
 	Page    uint `query:"page" validate:"required,gt=0"`
 	PerPage uint `query:"perPage" validate:"required,gt=0,lte=120"`
@@ -85,6 +135,27 @@ type RecipesFilterParams struct {
 	MicrowaveOnly *bool    `query:"microwaveOnly"`
 }
 
+type RecipeReadParams struct {
+	Servings uint    `query:"servings" validate:"omitempty,gt=0,lte=1000,excluded_with=Scale"`
+	Scale    float64 `query:"scale" validate:"omitempty,gt=0,lte=100"`
+	Units    string  `query:"units" validate:"omitempty,oneof=metric imperial"`
+}
+
+type RecipeRevisionDiffParams struct {
+	From uint `query:"from" validate:"required,gt=0"`
+	To   uint `query:"to" validate:"required,gt=0"`
+}
+
+type RecipeExportParams struct {
+	Format string `query:"format" validate:"required,oneof=md txt pdf"`
+}
+
+type RecipesExportParams struct {
+	RecipeExportParams
+	IDs   []string `query:"id" validate:"required,min=1,max=100,dive,uuid"`
+	Title string   `query:"title" validate:"max=60"`
+}
+
 type PantryItemsFilterParams struct {
 	PaginationParams
 	Name       string     `query:"name"`
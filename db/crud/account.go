@@ -0,0 +1,284 @@
+package crud
+
+import (
+	"github.com/google/uuid"
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/db/types"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Get the recipes a user owns, for exporting their account
+func GetRecipesByOwnerID(ownerID uuid.UUID) ([]db.ReadRecipe, error) {
+	var recipes []db.Recipe
+	if err := db.DB.Preload("Labels").Order("created_at").Find(&recipes, "owner_id = ?", ownerID).Error; err != nil {
+		return nil, err
+	}
+	readRecipes := make([]db.ReadRecipe, len(recipes))
+	for i, recipe := range recipes {
+		readRecipes[i] = recipe.IntoReadRecipe()
+	}
+	return readRecipes, nil
+}
+
+// Get the pantry locations a user owns along with their items, for exporting their account
+func GetPantryLocationsWithItemsByOwnerID(ownerID uuid.UUID) ([]types.ExportPantryLocation, error) {
+	var locations []db.PantryLocation
+	err := db.DB.
+		Preload("Items", func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("created_at")
+		}).
+		Preload("Items.Labels").
+		Order("created_at").
+		Find(&locations, "owner_id = ?", ownerID).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	exportLocations := make([]types.ExportPantryLocation, len(locations))
+	for i, location := range locations {
+		items := make([]types.ReadPantryItem, len(location.Items))
+		for j, item := range location.Items {
+			items[j] = intoReadPantryItem(item)
+		}
+		exportLocations[i] = types.ExportPantryLocation{
+			UUIDBase: location.UUIDBase,
+			TimeBase: location.TimeBase,
+			Name:     location.Name,
+			Items:    items,
+		}
+	}
+	return exportLocations, nil
+}
+
+// Content of an account export to import
+type AccountImport struct {
+	Recipes         []db.ReadRecipe
+	PantryLocations []types.ExportPantryLocation
+	Labels          []string
+}
+
+func firstOrCreateLabels(tx *gorm.DB, names []string) ([]db.Label, error) {
+	labels := make([]db.Label, len(names))
+	for i, name := range names {
+		labels[i] = db.Label{Name: name}
+		if err := tx.FirstOrCreate(&labels[i], "name = ?", name).Select("id").Error; err != nil {
+			return nil, err
+		}
+	}
+	return labels, nil
+}
+
+func importRecipes(tx *gorm.DB, userID uuid.UUID, recipes []db.ReadRecipe, keepConflicts bool, report *types.AccountImportReport) error {
+	// only recipes the user already had are conflicts, not copies within the import
+	conflicts := make([]bool, len(recipes))
+	if !keepConflicts {
+		for i, recipe := range recipes {
+			exists, err := doesUserHaveRecipe(tx, userID, recipe.Title, recipe.Info.Source)
+			if err != nil {
+				return err
+			}
+			conflicts[i] = exists
+		}
+	}
+
+	type createdRecipe struct {
+		source      db.ReadRecipe
+		ingredients []db.RecipeIngredient
+	}
+	var created []createdRecipe
+	for i, recipe := range recipes {
+		if conflicts[i] {
+			report.Recipes.Skipped++
+			continue
+		}
+
+		createRecipe := recipe.IntoCreateRecipe()
+		// households are not part of an export
+		createRecipe.HouseholdID = nil
+		// prepared like a new recipe, with recipes used as ingredients checked once linked
+		var ingredients []db.RecipeIngredient
+		if createRecipe.Ingredients != nil {
+			var err error
+			if ingredients, err = prepareIngredients(createRecipe.Ingredients); err != nil {
+				return err
+			}
+			createRecipe.Ingredients = ingredients
+		}
+		if createRecipe.Steps != nil {
+			steps, err := prepareSteps(createRecipe.Steps, len(ingredients))
+			if err != nil {
+				return err
+			}
+			createRecipe.Steps = steps
+		}
+		newRecipe := createRecipe.IntoRecipe(userID, nil)
+		newRecipe.TimeBase = recipe.TimeBase
+		labels, err := firstOrCreateLabels(tx, recipe.Labels)
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(&newRecipe).Association("Labels").Append(labels); err != nil {
+			return err
+		}
+		report.IDs[recipe.ID] = newRecipe.ID
+		report.Recipes.Created++
+		created = append(created, createdRecipe{source: recipe, ingredients: ingredients})
+	}
+
+	// ids are only known once created, so forks and recipes used as
+	// ingredients are linked afterwards, before the first revision
+	for _, item := range created {
+		recipe := item.source
+		recipeID := report.IDs[recipe.ID]
+		if recipe.ForkedFromID != nil {
+			if forkedFromID, ok, err := importedRecipeID(tx, userID, *recipe.ForkedFromID, report); err != nil {
+				return err
+			} else if ok {
+				if err := tx.Model(&db.Recipe{}).
+					Where("id = ?", recipeID).
+					Update("forked_from_id", forkedFromID).
+					Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		if ingredients := item.ingredients; len(subRecipeIDs(ingredients)) != 0 {
+			for i, ingredient := range ingredients {
+				if ingredient.RecipeID == nil {
+					continue
+				}
+				linkedID, ok, err := importedRecipeID(tx, userID, *ingredient.RecipeID, report)
+				if err != nil {
+					return err
+				} else if ok {
+					ingredients[i].RecipeID = &linkedID
+				} else {
+					ingredients[i].RecipeID = nil
+				}
+			}
+			if err := fillSubRecipeNames(tx, ingredients); err != nil {
+				return err
+			} else if err := checkRecipeCycle(tx, recipeID, ingredients); err != nil {
+				return err
+			}
+			if err := tx.Model(&db.Recipe{}).
+				Where("id = ?", recipeID).
+				Update("ingredients", datatypes.NewJSONType(ingredients)).
+				Error; err != nil {
+				return err
+			}
+		}
+
+		if err := createRecipeRevision(tx, recipeID, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get the id a recipe from an export has now, keeping the id of a recipe
+// that wasn't exported if the user can still see it. False when they can't
+func importedRecipeID(tx *gorm.DB, userID uuid.UUID, recipeID uuid.UUID, report *types.AccountImportReport) (uuid.UUID, bool, error) {
+	if importedID, ok := report.IDs[recipeID]; ok {
+		return importedID, true, nil
+	}
+	role, err := getRecipeRole(tx, userID, recipeID)
+	if err != nil {
+		return uuid.UUID{}, false, err
+	}
+	return recipeID, role != "", nil
+}
+
+// Import a pantry location, merging it into an existing location when one is given
+func importPantryLocation(tx *gorm.DB, userID uuid.UUID, location types.ExportPantryLocation, existing *db.PantryLocation, report *types.AccountImportReport) error {
+	var target db.PantryLocation
+	merged := existing != nil
+	if merged {
+		target = *existing
+		report.PantryLocations.Skipped++
+	} else {
+		target = db.PantryLocation{
+			TimeBase: location.TimeBase,
+			Name:     location.Name,
+			OwnerId:  userID,
+		}
+		if err := tx.Create(&target).Error; err != nil {
+			return err
+		}
+		report.PantryLocations.Created++
+	}
+	report.IDs[location.ID] = target.ID
+
+	for _, item := range location.Items {
+		// items already in a merged location are skipped
+		if merged {
+			query := tx.Model(&db.PantryItem{}).Where("location_id = ? AND name = ?", target.ID, item.Name)
+			if item.Expiry == nil {
+				query = query.Where("expiry IS NULL")
+			} else {
+				query = query.Where("expiry = ?", *item.Expiry)
+			}
+			var count int64
+			if err := query.Count(&count).Error; err != nil {
+				return err
+			} else if count != 0 {
+				report.PantryItems.Skipped++
+				continue
+			}
+		}
+
+		labels, err := firstOrCreateLabels(tx, item.Labels)
+		if err != nil {
+			return err
+		}
+		newItem := db.PantryItem{
+			TimeBase:   item.TimeBase,
+			Name:       item.Name,
+			LocationId: target.ID,
+			Quantity:   item.Quantity,
+			Notes:      item.Notes,
+			Expiry:     item.Expiry,
+		}
+		if err := tx.Create(&newItem).Association("Labels").Append(labels); err != nil {
+			return err
+		}
+		report.IDs[item.ID] = newItem.ID
+		report.PantryItems.Created++
+	}
+	return nil
+}
+
+// Import an exported account into a user's account, giving everything new ids.
+// Unless keepConflicts is set, recipes the user already has are skipped
+// and pantry locations with the same name are merged into
+func ImportAccount(userID uuid.UUID, data AccountImport, keepConflicts bool) (types.AccountImportReport, error) {
+	report := types.AccountImportReport{IDs: map[uuid.UUID]uuid.UUID{}}
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := firstOrCreateLabels(tx, data.Labels); err != nil {
+			return err
+		}
+		if err := importRecipes(tx, userID, data.Recipes, keepConflicts, &report); err != nil {
+			return err
+		}
+
+		existingLocations := map[string]*db.PantryLocation{}
+		if !keepConflicts {
+			var locations []db.PantryLocation
+			if err := tx.Find(&locations, "owner_id = ?", userID).Error; err != nil {
+				return err
+			}
+			for i := range locations {
+				existingLocations[locations[i].Name] = &locations[i]
+			}
+		}
+		for _, location := range data.PantryLocations {
+			if err := importPantryLocation(tx, userID, location, existingLocations[location.Name], &report); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return report, err
+}
@@ -0,0 +1,126 @@
+package crud
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/db/dbtest"
+)
+
+// A recipe as it appears in an export
+func exportedRecipe(title string, ingredients []db.RecipeIngredient, steps []db.RecipeStep) db.ReadRecipe {
+	return db.ReadRecipe{
+		UUIDBase:    db.UUIDBase{ID: uuid.New()},
+		Title:       title,
+		Ingredients: &ingredients,
+		Steps:       &steps,
+	}
+}
+
+func TestImportAccountPreparesRecipes(t *testing.T) {
+	dbtest.Setup(t)
+	userID := dbtest.CreateUser(t, "importer").ID
+	otherID := dbtest.CreateUser(t, "other").ID
+	private, err := CreateRecipe(db.CreateRecipe{Title: "Private"}, otherID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sauce := exportedRecipe("Sauce", []db.RecipeIngredient{{Name: "tomatoes", Amount: 2, UnitType: "Tablespoons"}}, nil)
+	pasta := exportedRecipe("Pasta", []db.RecipeIngredient{
+		{Name: "", Amount: 1, RecipeID: &sauce.ID},
+		{Name: "stock", Amount: 1, RecipeID: &private.ID},
+	}, []db.RecipeStep{{Description: "Mix.", IngredientIndexes: []uint{0, 1}}})
+
+	report, err := ImportAccount(userID, AccountImport{Recipes: []db.ReadRecipe{pasta, sauce}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := GetRecipeById(report.IDs[sauce.ID])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unit := (*imported.Ingredients)[0].UnitType; unit != "tbsp" {
+		t.Errorf("unit = %q, want tbsp", unit)
+	}
+
+	imported, err = GetRecipeById(report.IDs[pasta.ID])
+	if err != nil {
+		t.Fatal(err)
+	}
+	ingredients := *imported.Ingredients
+	if id := ingredients[0].RecipeID; id == nil || *id != report.IDs[sauce.ID] {
+		t.Errorf("sauce linked to %v, want the imported sauce", id)
+	}
+	if ingredients[0].Name != "Sauce" {
+		t.Errorf("name = %q, want the linked recipe's title", ingredients[0].Name)
+	}
+	// a recipe the user can't see is not linked to
+	if ingredients[1].RecipeID != nil {
+		t.Errorf("stock linked to %v, want no link", ingredients[1].RecipeID)
+	}
+	revisions, err := GetRecipeRevisions(imported.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 1 {
+		t.Errorf("revisions = %d, want 1", len(revisions))
+	}
+}
+
+func TestImportAccountRejectsInvalidRecipes(t *testing.T) {
+	first := exportedRecipe("First", nil, nil)
+	second := exportedRecipe("Second", []db.RecipeIngredient{{Name: "first", RecipeID: &first.ID}}, nil)
+	*first.Ingredients = []db.RecipeIngredient{{Name: "second", RecipeID: &second.ID}}
+
+	tests := []struct {
+		name    string
+		recipes []db.ReadRecipe
+		want    error
+	}{
+		{"cycle", []db.ReadRecipe{first, second}, ErrRecipeCycle},
+		{
+			"itself",
+			[]db.ReadRecipe{func() db.ReadRecipe {
+				recipe := exportedRecipe("Itself", nil, nil)
+				*recipe.Ingredients = []db.RecipeIngredient{{Name: "itself", RecipeID: &recipe.ID}}
+				return recipe
+			}()},
+			ErrRecipeCycle,
+		},
+		{
+			"step ingredient",
+			[]db.ReadRecipe{exportedRecipe(
+				"Steps",
+				[]db.RecipeIngredient{{Name: "flour"}},
+				[]db.RecipeStep{{Description: "Mix.", IngredientIndexes: []uint{1}}},
+			)},
+			ErrStepIngredientNotFound,
+		},
+		{
+			"nutrition",
+			[]db.ReadRecipe{exportedRecipe(
+				"Nutrition",
+				[]db.RecipeIngredient{{Name: "flour", Nutrition: &db.Nutrition{Calories: -1}}},
+				nil,
+			)},
+			ErrInvalidNutrition,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dbtest.Setup(t)
+			userID := dbtest.CreateUser(t, "importer").ID
+			if _, err := ImportAccount(userID, AccountImport{Recipes: test.recipes}, false); !errors.Is(err, test.want) {
+				t.Errorf("error = %v, want %v", err, test.want)
+			}
+			if count, err := GetRecipesByUserIDCount(userID); err != nil {
+				t.Fatal(err)
+			} else if count != 0 {
+				t.Errorf("recipes = %d, want none imported", count)
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package crud
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/db/types"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAlreadyHouseholdMember = errors.New("user is already a member")
+	ErrLastHouseholdOwner     = errors.New("household must have an owner")
+)
+
+// Subquery of the household ids the user is a member of
+func userHouseholdIDs(tx *gorm.DB, userID uuid.UUID) *gorm.DB {
+	return tx.Model(&db.HouseholdMember{}).Select("household_id").Where("user_id = ?", userID)
+}
+
+func CreateHousehold(household types.CreateHousehold, ownerID uuid.UUID) (types.ReadHousehold, error) {
+	newHousehold := db.Household{Name: household.Name}
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&newHousehold).Error; err != nil {
+			return err
+		}
+		return tx.Create(&db.HouseholdMember{
+			HouseholdID: newHousehold.ID,
+			UserID:      ownerID,
+			Role:        db.HouseholdOwner,
+		}).Error
+	})
+	return types.ReadHousehold{
+		UUIDBase: newHousehold.UUIDBase,
+		TimeBase: newHousehold.TimeBase,
+		Name:     newHousehold.Name,
+		Role:     db.HouseholdOwner,
+	}, err
+}
+
+func GetHouseholdsByUserID(userID uuid.UUID) ([]types.ReadHousehold, error) {
+	var households []types.ReadHousehold
+	err := db.DB.
+		Model(&db.Household{}).
+		Select("households.*, household_members.role").
+		Joins("JOIN household_members ON households.id = household_members.household_id").
+		Where("household_members.user_id = ?", userID).
+		Order("households.created_at ASC").
+		Scan(&households).
+		Error
+	return households, err
+}
+
+func GetHouseholdByID(householdID uuid.UUID) (db.Household, error) {
+	var household db.Household
+	err := db.DB.First(&household, "id = ?", householdID).Error
+	return household, err
+}
+
+func GetHouseholdMembers(householdID uuid.UUID) ([]types.ReadHouseholdMember, error) {
+	var members []types.ReadHouseholdMember
+	err := db.DB.
+		Model(&db.HouseholdMember{}).
+		Select("household_members.user_id, users.username, household_members.role").
+		Joins("JOIN users ON household_members.user_id = users.id").
+		Where("household_members.household_id = ?", householdID).
+		Order("household_members.created_at ASC").
+		Scan(&members).
+		Error
+	return members, err
+}
+
+// Get the role a user has in a household, empty when they are not a member
+func GetHouseholdRole(userID uuid.UUID, householdID uuid.UUID) (db.HouseholdRole, error) {
+	return getHouseholdRole(db.DB, userID, householdID)
+}
+
+func getHouseholdRole(tx *gorm.DB, userID uuid.UUID, householdID uuid.UUID) (db.HouseholdRole, error) {
+	var members []db.HouseholdMember
+	err := tx.
+		Where("household_id = ? AND user_id = ?", householdID, userID).
+		Limit(1).
+		Find(&members).
+		Error
+	if err != nil || len(members) == 0 {
+		return "", err
+	}
+	return members[0].Role, nil
+}
+
+// Get the role a user has for a resource: owner when they created it,
+// otherwise their role in the household it belongs to
+func getResourceRole(tx *gorm.DB, userID uuid.UUID, ownerID uuid.UUID, householdID *uuid.UUID) (db.HouseholdRole, error) {
+	if ownerID == userID {
+		return db.HouseholdOwner, nil
+	} else if householdID == nil {
+		return "", nil
+	}
+	return getHouseholdRole(tx, userID, *householdID)
+}
+
+// Get the role a user has for a recipe, empty when it doesn't exist or can't be seen
+func GetRecipeRole(userID uuid.UUID, recipeID uuid.UUID) (db.HouseholdRole, error) {
+	return getRecipeRole(db.DB, userID, recipeID)
+}
+
+func getRecipeRole(tx *gorm.DB, userID uuid.UUID, recipeID uuid.UUID) (db.HouseholdRole, error) {
+	var recipes []db.Recipe
+	if err := tx.
+		Select("owner_id", "household_id").
+		Where("id = ?", recipeID).
+		Limit(1).
+		Find(&recipes).
+		Error; err != nil || len(recipes) == 0 {
+		return "", err
+	}
+	return getResourceRole(tx, userID, recipes[0].OwnerID, recipes[0].HouseholdID)
+}
+
+// Get the role a user has for a pantry location, empty when it doesn't exist or can't be seen
+func GetPantryLocationRole(userID uuid.UUID, locationID uuid.UUID) (db.HouseholdRole, error) {
+	var locations []db.PantryLocation
+	if err := db.DB.
+		Select("owner_id", "household_id").
+		Where("id = ?", locationID).
+		Limit(1).
+		Find(&locations).
+		Error; err != nil || len(locations) == 0 {
+		return "", err
+	}
+	return getResourceRole(db.DB, userID, locations[0].OwnerId, locations[0].HouseholdID)
+}
+
+// Get the role a user has for a pantry item, which comes from its location
+func GetPantryItemRole(userID uuid.UUID, itemID uuid.UUID) (db.HouseholdRole, error) {
+	var locationIDs []uuid.UUID
+	if err := db.DB.
+		Model(&db.PantryItem{}).
+		Where("id = ?", itemID).
+		Limit(1).
+		Pluck("location_id", &locationIDs).
+		Error; err != nil || len(locationIDs) == 0 {
+		return "", err
+	}
+	return GetPantryLocationRole(userID, locationIDs[0])
+}
+
+func UpdateHousehold(householdID uuid.UUID, household types.UpdateHousehold) error {
+	return db.DB.
+		Model(&db.Household{}).
+		Where("id = ?", householdID).
+		Update("name", household.Name).
+		Error
+}
+
+// Delete a household, its recipes and pantry locations are kept by their owners
+func DeleteHousehold(householdID uuid.UUID) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		return deleteHousehold(tx, householdID)
+	})
+}
+
+func deleteHousehold(tx *gorm.DB, householdID uuid.UUID) error {
+	if err := tx.
+		Model(&db.Recipe{}).
+		Where("household_id = ?", householdID).
+		Update("household_id", nil).
+		Error; err != nil {
+		return err
+	}
+	if err := tx.
+		Model(&db.PantryLocation{}).
+		Where("household_id = ?", householdID).
+		Update("household_id", nil).
+		Error; err != nil {
+		return err
+	}
+	if err := tx.Where("household_id = ?", householdID).Delete(&db.HouseholdMember{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("id = ?", householdID).Delete(&db.Household{}).Error
+}
+
+func AddHouseholdMember(householdID uuid.UUID, userID uuid.UUID, role db.HouseholdRole) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.
+			Model(&db.HouseholdMember{}).
+			Where("household_id = ? AND user_id = ?", householdID, userID).
+			Count(&count).
+			Error; err != nil {
+			return err
+		} else if count != 0 {
+			return ErrAlreadyHouseholdMember
+		}
+		return tx.Create(&db.HouseholdMember{
+			HouseholdID: householdID,
+			UserID:      userID,
+			Role:        role,
+		}).Error
+	})
+}
+
+// Ensure that changing the given member won't leave the household without an owner
+func checkOtherHouseholdOwner(tx *gorm.DB, householdID uuid.UUID, userID uuid.UUID) error {
+	var count int64
+	if err := tx.
+		Model(&db.HouseholdMember{}).
+		Where("household_id = ? AND user_id <> ? AND role = ?", householdID, userID, db.HouseholdOwner).
+		Count(&count).
+		Error; err != nil {
+		return err
+	} else if count == 0 {
+		return ErrLastHouseholdOwner
+	}
+	return nil
+}
+
+func UpdateHouseholdMemberRole(householdID uuid.UUID, userID uuid.UUID, role db.HouseholdRole) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		var member db.HouseholdMember
+		if err := tx.First(&member, "household_id = ? AND user_id = ?", householdID, userID).Error; err != nil {
+			return err
+		}
+		if member.Role == db.HouseholdOwner && role != db.HouseholdOwner {
+			if err := checkOtherHouseholdOwner(tx, householdID, userID); err != nil {
+				return err
+			}
+		}
+		return tx.
+			Model(&db.HouseholdMember{}).
+			Where("household_id = ? AND user_id = ?", householdID, userID).
+			Update("role", role).
+			Error
+	})
+}
+
+// Remove a member, taking their own recipes and pantry locations out of the household
+func RemoveHouseholdMember(householdID uuid.UUID, userID uuid.UUID) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		var member db.HouseholdMember
+		if err := tx.First(&member, "household_id = ? AND user_id = ?", householdID, userID).Error; err != nil {
+			return err
+		}
+		if member.Role == db.HouseholdOwner {
+			if err := checkOtherHouseholdOwner(tx, householdID, userID); err != nil {
+				return err
+			}
+		}
+		if err := tx.
+			Model(&db.Recipe{}).
+			Where("household_id = ? AND owner_id = ?", householdID, userID).
+			Update("household_id", nil).
+			Error; err != nil {
+			return err
+		}
+		if err := tx.
+			Model(&db.PantryLocation{}).
+			Where("household_id = ? AND owner_id = ?", householdID, userID).
+			Update("household_id", nil).
+			Error; err != nil {
+			return err
+		}
+		return tx.Where("household_id = ? AND user_id = ?", householdID, userID).Delete(&db.HouseholdMember{}).Error
+	})
+}
+
+// Remove a user from all their households, for when the user is deleted.
+// Empty households are deleted and ones left without an owner have their oldest member promoted
+func leaveAllHouseholds(tx *gorm.DB, userID uuid.UUID) error {
+	var householdIDs []uuid.UUID
+	if err := userHouseholdIDs(tx, userID).Pluck("household_id", &householdIDs).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&db.HouseholdMember{}).Error; err != nil {
+		return err
+	}
+	for _, householdID := range householdIDs {
+		var members []db.HouseholdMember
+		if err := tx.
+			Where("household_id = ?", householdID).
+			Order("created_at ASC").
+			Find(&members).
+			Error; err != nil {
+			return err
+		}
+		if len(members) == 0 {
+			if err := deleteHousehold(tx, householdID); err != nil {
+				return err
+			}
+			continue
+		}
+		hasOwner := false
+		for _, member := range members {
+			if member.Role == db.HouseholdOwner {
+				hasOwner = true
+				break
+			}
+		}
+		if !hasOwner {
+			if err := tx.
+				Model(&db.HouseholdMember{}).
+				Where("household_id = ? AND user_id = ?", householdID, members[0].UserID).
+				Update("role", db.HouseholdOwner).
+				Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Move a recipe into or out of a household, storing the result as a new revision by the given author
+func SetRecipeHousehold(recipeID uuid.UUID, householdID *uuid.UUID, authorID uuid.UUID) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureRecipeRevision(tx, recipeID); err != nil {
+			return err
+		}
+		if err := tx.
+			Model(&db.Recipe{}).
+			Where("id = ?", recipeID).
+			Update("household_id", householdID).
+			Error; err != nil {
+			return err
+		}
+		return createRecipeRevision(tx, recipeID, authorID)
+	})
+}
+
+func SetPantryLocationHousehold(locationID uuid.UUID, householdID *uuid.UUID) error {
+	return db.DB.
+		Model(&db.PantryLocation{}).
+		Where("id = ?", locationID).
+		Update("household_id", householdID).
+		Error
+}
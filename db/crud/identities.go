@@ -0,0 +1,141 @@
+package crud
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/my-cooking-codex/api/config"
+	"github.com/my-cooking-codex/api/core"
+	"github.com/my-cooking-codex/api/db"
+	"gorm.io/gorm"
+)
+
+func CreateOIDCLoginRequest(
+	state string,
+	nonce string,
+	codeVerifier string,
+	linkUserID *uuid.UUID,
+	expiresAt time.Time,
+) error {
+	return db.DB.Create(&db.OIDCLoginRequest{
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		LinkUserID:   linkUserID,
+		ExpiresAt:    expiresAt,
+	}).Error
+}
+
+// Get a pending login request and remove it, so it can only be used once
+func TakeOIDCLoginRequest(state string) (db.OIDCLoginRequest, error) {
+	var loginRequest db.OIDCLoginRequest
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		// tidy up requests that were never completed
+		if err := tx.Where("expires_at <= ?", time.Now()).Delete(&db.OIDCLoginRequest{}).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&loginRequest, "state = ?", state).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&loginRequest).Error
+	})
+	return loginRequest, err
+}
+
+func GetUserByIdentity(issuer string, subject string) (db.User, error) {
+	var user db.User
+	err := db.DB.
+		Joins("JOIN user_identities ON user_identities.user_id = users.id").
+		Where("user_identities.issuer = ? AND user_identities.subject = ?", issuer, subject).
+		First(&user).
+		Error
+	return user, err
+}
+
+func IsUsernameTaken(username string) (bool, error) {
+	var count int64
+	err := db.DB.Model(&db.User{}).Where("username = ?", username).Count(&count).Error
+	return count > 0, err
+}
+
+func CreateUserIdentity(userID uuid.UUID, issuer string, subject string) (db.UserIdentity, error) {
+	identity := db.UserIdentity{
+		UserID:  userID,
+		Issuer:  issuer,
+		Subject: subject,
+	}
+	err := db.DB.Create(&identity).Error
+	return identity, err
+}
+
+// Create a user that logs in through an identity provider, following
+// the registration mode like normal registration. The first user can
+// always register and is made an admin
+func CreateUserWithIdentity(
+	username string,
+	issuer string,
+	subject string,
+	mode config.RegistrationMode,
+	inviteCode *string,
+) (db.User, error) {
+	// the password is never given out, so it cannot be used to log in
+	password, _, err := core.CreateOpaqueToken()
+	if err != nil {
+		return db.User{}, err
+	}
+	newUser := db.User{Username: username, IdentityOnly: true}
+	newUser.SetPassword(password)
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&db.User{}).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			newUser.IsAdmin = true
+		} else if mode == config.RegistrationClosed {
+			return ErrRegistrationClosed
+		} else if mode == config.RegistrationInvite {
+			if inviteCode == nil {
+				return ErrInviteCodeInvalid
+			}
+			if err := redeemInviteCode(tx, *inviteCode); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&newUser).Error; err != nil {
+			return err
+		}
+		return tx.Create(&db.UserIdentity{
+			UserID:  newUser.ID,
+			Issuer:  issuer,
+			Subject: subject,
+		}).Error
+	})
+	return newUser, err
+}
+
+func GetUserIdentitiesByUserID(userID uuid.UUID) ([]db.UserIdentity, error) {
+	var identities []db.UserIdentity
+	err := db.DB.Where("user_id = ?", userID).Order("created_at ASC").Find(&identities).Error
+	return identities, err
+}
+
+func DoesUserOwnIdentity(userID uuid.UUID, identityID uuid.UUID) (bool, error) {
+	var count int64
+	err := db.DB.
+		Model(&db.UserIdentity{}).
+		Where("id = ? AND user_id = ?", identityID, userID).
+		Count(&count).
+		Error
+	return count > 0, err
+}
+
+func CountUserIdentities(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := db.DB.Model(&db.UserIdentity{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+func DeleteUserIdentity(identityID uuid.UUID) error {
+	return db.DB.Where("id = ?", identityID).Delete(&db.UserIdentity{}).Error
+}
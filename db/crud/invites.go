@@ -0,0 +1,61 @@
+package crud
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/my-cooking-codex/api/core"
+	"github.com/my-cooking-codex/api/db"
+	"gorm.io/gorm"
+)
+
+var ErrInviteCodeInvalid = errors.New("invite code is invalid, used up or expired")
+
+func CreateInviteCode(newInvite db.CreateInviteCode, createdByID uuid.UUID) (db.InviteCode, error) {
+	code, _, err := core.CreateOpaqueToken()
+	if err != nil {
+		return db.InviteCode{}, err
+	}
+	invite := db.InviteCode{
+		Code:        code,
+		CreatedByID: createdByID,
+		MaxUses:     newInvite.MaxUses,
+		ExpiresAt:   newInvite.ExpiresAt,
+	}
+	if invite.MaxUses == 0 {
+		invite.MaxUses = 1
+	}
+	err = db.DB.Create(&invite).Error
+	return invite, err
+}
+
+func GetInviteCodes() ([]db.InviteCode, error) {
+	var invites []db.InviteCode
+	err := db.DB.Order("created_at DESC").Find(&invites).Error
+	return invites, err
+}
+
+func GetInviteCodeByID(inviteID uuid.UUID) (db.InviteCode, error) {
+	var invite db.InviteCode
+	err := db.DB.First(&invite, "id = ?", inviteID).Error
+	return invite, err
+}
+
+// Use up one of the invite code's uses, failing with ErrInviteCodeInvalid
+func redeemInviteCode(tx *gorm.DB, code string) error {
+	result := tx.
+		Model(&db.InviteCode{}).
+		Where("code = ? AND uses < max_uses AND (expires_at IS NULL OR expires_at > ?)", code, time.Now()).
+		Update("uses", gorm.Expr("uses + 1"))
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return ErrInviteCodeInvalid
+	}
+	return nil
+}
+
+func DeleteInviteCode(inviteID uuid.UUID) error {
+	return db.DB.Where("id = ?", inviteID).Delete(&db.InviteCode{}).Error
+}
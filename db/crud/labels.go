@@ -6,6 +6,7 @@ import (
 	"gorm.io/gorm"
 )
 
+// Get the labels used by everything the user can see, including their households
 func GetLabelNamesByUser(userID uuid.UUID) ([]string, error) {
 	var labels []string
 	tx := db.DB.Session(&gorm.Session{PrepareStmt: true})
@@ -14,22 +15,24 @@ func GetLabelNamesByUser(userID uuid.UUID) ([]string, error) {
 		Raw(`SELECT DISTINCT d1.name AS label_name
 FROM (
     SELECT labels.name
-    FROM users u
-    LEFT JOIN recipes r ON u.id = r.owner_id
-    LEFT JOIN recipe_labels r1 ON r.id = r1.recipe_id
-    LEFT JOIN labels ON r1.label_id = labels.id
-    WHERE u.id = ? AND labels.name IS NOT NULL
+    FROM recipes r
+    JOIN recipe_labels r1 ON r.id = r1.recipe_id
+    JOIN labels ON r1.label_id = labels.id
+    WHERE r.owner_id = ? OR r.household_id IN (
+        SELECT household_id FROM household_members WHERE user_id = ?
+    )
 
     UNION
 
     SELECT labels.name
-    FROM users u
-    LEFT JOIN pantry_locations pl ON u.id = pl.owner_id
-    LEFT JOIN pantry_items pi ON pl.id = pi.location_id
-    LEFT JOIN pantry_item_labels pil ON pi.id = pil.pantry_item_id
-    LEFT JOIN labels ON pil.label_id = labels.id
-    WHERE u.id = ? AND labels.name IS NOT NULL
-) AS d1;`, userID, userID).
+    FROM pantry_locations pl
+    JOIN pantry_items pi ON pl.id = pi.location_id
+    JOIN pantry_item_labels pil ON pi.id = pil.pantry_item_id
+    JOIN labels ON pil.label_id = labels.id
+    WHERE pl.owner_id = ? OR pl.household_id IN (
+        SELECT household_id FROM household_members WHERE user_id = ?
+    )
+) AS d1;`, userID, userID, userID, userID).
 		Scan(&labels).Error
 	return labels, err
 }
@@ -16,8 +16,9 @@ func CreatePantryLocation(
 	ownerID uuid.UUID,
 ) (db.PantryLocation, error) {
 	pantryLocation := db.PantryLocation{
-		OwnerId: ownerID,
-		Name:    newLocation.Name,
+		OwnerId:     ownerID,
+		HouseholdID: newLocation.HouseholdID,
+		Name:        newLocation.Name,
 	}
 	err := db.DB.Create(&pantryLocation).Error
 	return pantryLocation, err
@@ -29,20 +30,14 @@ func GetPantryLocationByID(pantryID uuid.UUID) (db.PantryLocation, error) {
 	return pantryLocation, err
 }
 
+// Get the pantry locations a user owns or can see through their households
 func GetPantryLocationsByUserID(userID uuid.UUID) ([]db.PantryLocation, error) {
 	var pantryLocations []db.PantryLocation
-	err := db.DB.Where("owner_id = ?", userID).Find(&pantryLocations).Error
-	return pantryLocations, err
-}
-
-func DoesUserOwnPantryLocation(userID uuid.UUID, locationID uuid.UUID) (bool, error) {
-	var count int64
 	err := db.DB.
-		Model(&db.PantryLocation{}).
-		Where("id = ? AND owner_id = ?", locationID, userID).
-		Count(&count).
+		Where("owner_id = ? OR household_id IN (?)", userID, userHouseholdIDs(db.DB, userID)).
+		Find(&pantryLocations).
 		Error
-	return count > 0, err
+	return pantryLocations, err
 }
 
 func UpdatePantryLocation(
@@ -87,13 +82,20 @@ func CreatePantryItem(
 
 func GetPantryItemCountByUserID(userID uuid.UUID) (int64, error) {
 	var count int64
-	return count, db.DB.Model(&db.PantryItem{}).Count(&count).Error
+	return count, db.DB.
+		Model(&db.PantryItem{}).
+		Joins("JOIN pantry_locations ON pantry_items.location_id = pantry_locations.id").
+		Where("pantry_locations.owner_id = ?", userID).
+		Count(&count).
+		Error
 }
 
-func GetPantryItemByID(itemID uuid.UUID) (types.ReadPantryItem, error) {
-	var item db.PantryItem
-	err := db.DB.Preload("Labels").First(&item, "id = ?", itemID).Error
-	readPantryItem := types.ReadPantryItem{
+func intoReadPantryItem(item db.PantryItem) types.ReadPantryItem {
+	labels := make([]string, len(item.Labels))
+	for i, label := range item.Labels {
+		labels[i] = label.Name
+	}
+	return types.ReadPantryItem{
 		UUIDBase:   item.UUIDBase,
 		TimeBase:   item.TimeBase,
 		Name:       item.Name,
@@ -101,15 +103,14 @@ func GetPantryItemByID(itemID uuid.UUID) (types.ReadPantryItem, error) {
 		Quantity:   item.Quantity,
 		Notes:      item.Notes,
 		Expiry:     item.Expiry,
-		Labels: func() []string {
-			labels := make([]string, len(item.Labels))
-			for i, label := range item.Labels {
-				labels[i] = label.Name
-			}
-			return labels
-		}(),
+		Labels:     labels,
 	}
-	return readPantryItem, err
+}
+
+func GetPantryItemByID(itemID uuid.UUID) (types.ReadPantryItem, error) {
+	var item db.PantryItem
+	err := db.DB.Preload("Labels").First(&item, "id = ?", itemID).Error
+	return intoReadPantryItem(item), err
 }
 
 type PantryItemsFilters struct {
@@ -132,7 +133,11 @@ func GetPantryItemsByUserID(
 		Limit(int(limit)).
 		Order("expiry ASC").
 		Joins("JOIN pantry_locations ON pantry_items.location_id = pantry_locations.id").
-		Where("pantry_locations.owner_id = ?", userID)
+		Where(
+			"(pantry_locations.owner_id = ? OR pantry_locations.household_id IN (?))",
+			userID,
+			userHouseholdIDs(db.DB, userID),
+		)
 
 	if name := strings.TrimSpace(filters.Name); name != "" {
 		query = query.Where("lower(pantry_items.name) LIKE lower(?)", "%"+name+"%")
@@ -164,39 +169,12 @@ func GetPantryItemsByUserID(
 	var readItems = make([]types.ReadPantryItem, len(items))
 
 	for i, item := range items {
-		readItems[i] = types.ReadPantryItem{
-			UUIDBase:   item.UUIDBase,
-			TimeBase:   item.TimeBase,
-			Name:       item.Name,
-			LocationId: item.LocationId,
-			Quantity:   item.Quantity,
-			Notes:      item.Notes,
-			Expiry:     item.Expiry,
-			Labels: func() []string {
-				labels := make([]string, len(item.Labels))
-				for i, label := range item.Labels {
-					labels[i] = label.Name
-				}
-				return labels
-			}(),
-		}
+		readItems[i] = intoReadPantryItem(item)
 	}
 
 	return readItems, err
 }
 
-func DoesUserOwnPantryItem(userID uuid.UUID, pantryItemID uuid.UUID) (bool, error) {
-	var count int64
-	err := db.DB.
-		Model(&db.PantryItem{}).
-		Preload("Location").
-		Joins("JOIN pantry_locations ON pantry_items.location_id = pantry_locations.id").
-		Where("pantry_items.id = ? AND pantry_locations.owner_id = ?", pantryItemID, userID).
-		Count(&count).
-		Error
-	return count != 0, err
-}
-
 func UpdatePantryItem(
 	itemID uuid.UUID,
 	update core.SelectedUpdate[types.UpdatePantryItem],
@@ -1,14 +1,78 @@
 package crud
 
 import (
+	"errors"
 	"strings"
 
 	"github.com/google/uuid"
 	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/ingredients"
+	"github.com/my-cooking-codex/api/units"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrStepIngredientNotFound = errors.New("step uses an ingredient the recipe doesn't have")
+	ErrInvalidNutrition       = errors.New("ingredient nutrition can't be negative")
+)
+
+// Drop timers that were only detected from the descriptions of steps, so they
+// follow changes to them, and check the ingredients steps use exist
+func prepareSteps(steps []db.RecipeStep, ingredientCount int) ([]db.RecipeStep, error) {
+	prepared := make([]db.RecipeStep, len(steps))
+	for i, step := range steps {
+		var timers []db.StepTimer
+		for _, timer := range step.Timers {
+			if !timer.Detected {
+				timers = append(timers, timer)
+			}
+		}
+		step.Timers = timers
+		for _, index := range step.IngredientIndexes {
+			if int(index) >= ingredientCount {
+				return nil, ErrStepIngredientNotFound
+			}
+		}
+		prepared[i] = step
+	}
+	return prepared, nil
+}
+
+// Check the nutrition of ingredients and write their units the standard way
+func prepareIngredients(ingredients []db.RecipeIngredient) ([]db.RecipeIngredient, error) {
+	prepared := make([]db.RecipeIngredient, len(ingredients))
+	for i, ingredient := range ingredients {
+		if ingredient.Nutrition != nil && !ingredient.Nutrition.IsValid() {
+			return nil, ErrInvalidNutrition
+		}
+		ingredient.UnitType = units.Normalise(ingredient.UnitType)
+		prepared[i] = ingredient
+	}
+	return prepared, nil
+}
+
 func CreateRecipe(recipe db.CreateRecipe, userID uuid.UUID) (db.ReadRecipe, error) {
+	if recipe.IngredientsText != nil {
+		recipe.Ingredients = append(recipe.Ingredients, ingredients.ParseText(*recipe.IngredientsText)...)
+	}
+	if recipe.Ingredients != nil {
+		prepared, err := prepareIngredients(recipe.Ingredients)
+		if err != nil {
+			return db.ReadRecipe{}, err
+		}
+		recipe.Ingredients = prepared
+		if err := fillSubRecipeNames(db.DB, recipe.Ingredients); err != nil {
+			return db.ReadRecipe{}, err
+		}
+	}
+	if recipe.Steps != nil {
+		steps, err := prepareSteps(recipe.Steps, len(recipe.Ingredients))
+		if err != nil {
+			return db.ReadRecipe{}, err
+		}
+		recipe.Steps = steps
+	}
 	var newRecipe = recipe.IntoRecipe(userID, nil)
 	labels := make([]db.Label, len(recipe.Labels))
 
@@ -20,7 +84,10 @@ func CreateRecipe(recipe db.CreateRecipe, userID uuid.UUID) (db.ReadRecipe, erro
 			}
 		}
 
-		return tx.Create(&newRecipe).Association("Labels").Append(labels)
+		if err := tx.Create(&newRecipe).Association("Labels").Append(labels); err != nil {
+			return err
+		}
+		return createRecipeRevision(tx, newRecipe.ID, userID)
 	})
 
 	return newRecipe.IntoReadRecipe(), err
@@ -33,6 +100,7 @@ type RecipesFilterParams struct {
 	MicrowaveOnly *bool
 }
 
+// Get the recipes a user owns or can see through their households
 func GetRecipesByUserID(userID uuid.UUID, offset uint, limit uint, filters RecipesFilterParams) ([]db.ReadRecipe, error) {
 	var recipes []db.Recipe
 
@@ -41,7 +109,7 @@ func GetRecipesByUserID(userID uuid.UUID, offset uint, limit uint, filters Recip
 		Offset(int(offset)).
 		Limit(int(limit)).
 		Order("created_at DESC").
-		Where("owner_id = ?", userID)
+		Where("(recipes.owner_id = ? OR recipes.household_id IN (?))", userID, userHouseholdIDs(db.DB, userID))
 
 	// add title filter if present
 	if filters.Title != nil {
@@ -97,17 +165,68 @@ func GetRecipeById(id uuid.UUID) (db.ReadRecipe, error) {
 	return recipe.IntoReadRecipe(), nil
 }
 
-func DoesUserOwnRecipe(userID uuid.UUID, recipeId uuid.UUID) (bool, error) {
-	var count int64
-	err := db.DB.Model(&db.Recipe{}).Where("id = ?", recipeId).Count(&count).Error
-	return count > 0, err
-}
-
-func UpdateRecipe(recipeID uuid.UUID, recipe db.UpdateRecipe) (db.ReadRecipe, error) {
+// Update a recipe, storing the result as a new revision by the given author
+func UpdateRecipe(recipeID uuid.UUID, recipe db.UpdateRecipe, authorID uuid.UUID) (db.ReadRecipe, error) {
 	var updatedRecipe db.Recipe
+	if recipe.Ingredients != nil {
+		normalised := make([]db.UpdateIngredient, len(*recipe.Ingredients))
+		for i, ingredient := range *recipe.Ingredients {
+			if ingredient.Nutrition != nil && !ingredient.Nutrition.IsValid() {
+				return db.ReadRecipe{}, ErrInvalidNutrition
+			}
+			ingredient.UnitType = units.Normalise(ingredient.UnitType)
+			normalised[i] = ingredient
+		}
+		recipe.Ingredients = &normalised
+	}
 
 	err := db.DB.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Model(&updatedRecipe).Where("id = ?", recipeID).Updates(recipe.IntoRecipe()).Error; err != nil {
+		if err := ensureRecipeRevision(tx, recipeID); err != nil {
+			return err
+		}
+
+		updates := recipe.IntoRecipe()
+		if updates.Ingredients != nil {
+			ingredients := updates.Ingredients.Data()
+			if err := fillSubRecipeNames(tx, ingredients); err != nil {
+				return err
+			} else if err := checkRecipeCycle(tx, recipeID, ingredients); err != nil {
+				return err
+			}
+			updatedIngredients := datatypes.NewJSONType(ingredients)
+			updates.Ingredients = &updatedIngredients
+		}
+		if updates.Steps != nil {
+			var ingredientCount int
+			if updates.Ingredients != nil {
+				ingredientCount = len(updates.Ingredients.Data())
+			} else {
+				var existing db.Recipe
+				if err := tx.Select("id", "ingredients").First(&existing, "id = ?", recipeID).Error; err != nil {
+					return err
+				} else if existing.Ingredients != nil {
+					ingredientCount = len(existing.Ingredients.Data())
+				}
+			}
+			steps, err := prepareSteps(updates.Steps.Data(), ingredientCount)
+			if err != nil {
+				return err
+			}
+			updatedSteps := datatypes.NewJSONType(steps)
+			updates.Steps = &updatedSteps
+		} else if updates.Ingredients != nil {
+			// steps kept as they are still have to use ingredients the recipe has
+			var existing db.Recipe
+			if err := tx.Select("id", "steps").First(&existing, "id = ?", recipeID).Error; err != nil {
+				return err
+			} else if existing.Steps != nil {
+				if _, err := prepareSteps(existing.Steps.Data(), len(updates.Ingredients.Data())); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := tx.Model(&updatedRecipe).Where("id = ?", recipeID).Updates(updates).Error; err != nil {
 			return err
 		}
 
@@ -120,27 +239,99 @@ func UpdateRecipe(recipeID uuid.UUID, recipe db.UpdateRecipe) (db.ReadRecipe, er
 				}
 			}
 			var foundRecipe db.Recipe
-			if err := tx.First(&foundRecipe, recipeID).Select("id").Error; err != nil {
+			if err := tx.First(&foundRecipe, "id = ?", recipeID).Select("id").Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&foundRecipe).Association("Labels").Replace(&labels); err != nil {
 				return err
 			}
-			return tx.Model(&foundRecipe).Association("Labels").Replace(&labels)
 		}
 
-		return nil
+		return createRecipeRevision(tx, recipeID, authorID)
 	})
 
 	return updatedRecipe.IntoReadRecipe(), err
 }
 
-func UpdateRecipeImage(recipeID uuid.UUID, imageID *uuid.UUID) error {
-	var updatedRecipe db.Recipe
-	if err := db.DB.Model(&updatedRecipe).Where("id = ?", recipeID).Updates(map[string]any{"image_id": imageID}).Error; err != nil {
-		return err
+// Create a copy of a recipe for the user, linked back to the original. The
+// ingredients replace the original's, so links the user can't follow are left out
+func CopyRecipe(recipeID uuid.UUID, userID uuid.UUID, imageID *uuid.UUID, ingredients []db.RecipeIngredient) (db.ReadRecipe, error) {
+	var newRecipe db.Recipe
+	var copiedIngredients *datatypes.JSONType[[]db.RecipeIngredient]
+	if ingredients != nil {
+		prepared, err := prepareIngredients(ingredients)
+		if err != nil {
+			return db.ReadRecipe{}, err
+		}
+		value := datatypes.NewJSONType(prepared)
+		copiedIngredients = &value
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var recipe db.Recipe
+		if err := tx.Preload("Labels").First(&recipe, "id = ?", recipeID).Error; err != nil {
+			return err
+		}
+		newRecipe = db.Recipe{
+			OwnerID:          userID,
+			Title:            recipe.Title,
+			Info:             recipe.Info,
+			ShortDescription: recipe.ShortDescription,
+			LongDescription:  recipe.LongDescription,
+			Ingredients:      copiedIngredients,
+			Steps:            recipe.Steps,
+			ImageID:          imageID,
+			ForkedFromID:     &recipe.ID,
+		}
+		if err := tx.Create(&newRecipe).Association("Labels").Append(recipe.Labels); err != nil {
+			return err
+		}
+		return createRecipeRevision(tx, newRecipe.ID, userID)
+	})
+
+	return newRecipe.IntoReadRecipe(), err
+}
+
+// Whether a user already owns a recipe with the same title & source,
+// used to skip duplicates when importing
+func DoesUserHaveRecipe(userID uuid.UUID, title string, source *string) (bool, error) {
+	return doesUserHaveRecipe(db.DB, userID, title, source)
+}
+
+func doesUserHaveRecipe(tx *gorm.DB, userID uuid.UUID, title string, source *string) (bool, error) {
+	var count int64
+	query := tx.Model(&db.Recipe{}).Where("owner_id = ? AND LOWER(title) = LOWER(?)", userID, title)
+	if source == nil {
+		query = query.Where("info_source IS NULL")
+	} else {
+		query = query.Where("info_source = ?", *source)
 	}
-	return nil
+	err := query.Count(&count).Error
+	return count != 0, err
+}
+
+// Set or clear the image of a recipe, storing the result as a new revision by the given author
+func UpdateRecipeImage(recipeID uuid.UUID, imageID *uuid.UUID, authorID uuid.UUID) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := ensureRecipeRevision(tx, recipeID); err != nil {
+			return err
+		}
+		if err := tx.Model(&db.Recipe{}).Where("id = ?", recipeID).Updates(map[string]any{"image_id": imageID}).Error; err != nil {
+			return err
+		}
+		return createRecipeRevision(tx, recipeID, authorID)
+	})
 }
 
 func DeleteRecipe(recipeID uuid.UUID) error {
-	item := db.Recipe{UUIDBase: db.UUIDBase{ID: recipeID}}
-	return db.DB.Select("Labels").Delete(&item).Error
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("recipe_id = ?", recipeID).Delete(&db.RecipeShare{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("recipe_id = ?", recipeID).Delete(&db.RecipeRevision{}).Error; err != nil {
+			return err
+		}
+		item := db.Recipe{UUIDBase: db.UUIDBase{ID: recipeID}}
+		return tx.Select("Labels").Delete(&item).Error
+	})
 }
@@ -0,0 +1,94 @@
+package crud
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/db/dbtest"
+	"github.com/my-cooking-codex/api/db/types"
+)
+
+func TestUpdateRecipeStepIngredients(t *testing.T) {
+	dbtest.Setup(t)
+	userID := dbtest.CreateUser(t, "cook").ID
+	recipe, err := CreateRecipe(db.CreateRecipe{
+		Title: "Pancakes",
+		Ingredients: []db.RecipeIngredient{
+			{Name: "flour", Amount: 200, UnitType: "g"},
+			{Name: "milk", Amount: 300, UnitType: "ml"},
+		},
+		Steps: []db.RecipeStep{{Description: "Whisk in the milk.", IngredientIndexes: []uint{1}}},
+	}, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flour := []db.UpdateIngredient{{Name: "flour", Amount: 200, UnitType: "g"}}
+	tests := []struct {
+		name   string
+		update db.UpdateRecipe
+		want   error
+	}{
+		{"more ingredients", db.UpdateRecipe{Ingredients: &[]db.UpdateIngredient{
+			{Name: "flour", Amount: 200, UnitType: "g"},
+			{Name: "milk", Amount: 300, UnitType: "ml"},
+			{Name: "egg", Amount: 1},
+		}}, nil},
+		// the stored step uses the milk, which is removed
+		{"stored step loses its ingredient", db.UpdateRecipe{Ingredients: &flour}, ErrStepIngredientNotFound},
+		{"steps updated with the ingredients", db.UpdateRecipe{
+			Ingredients: &flour,
+			Steps:       &[]db.UpdateStep{{Description: "Mix.", IngredientIndexes: []uint{0}}},
+		}, nil},
+		{"step uses a missing ingredient", db.UpdateRecipe{
+			Steps: &[]db.UpdateStep{{Description: "Mix.", IngredientIndexes: []uint{1}}},
+		}, ErrStepIngredientNotFound},
+	}
+	for _, test := range tests {
+		if _, err := UpdateRecipe(recipe.ID, test.update, userID); !errors.Is(err, test.want) {
+			t.Errorf("%s: error = %v, want %v", test.name, err, test.want)
+		}
+	}
+
+	updated, err := GetRecipeById(recipe.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*updated.Ingredients) != 1 || (*updated.Steps)[0].IngredientIndexes[0] != 0 {
+		t.Errorf("recipe = %+v %+v, want the last successful update", *updated.Ingredients, *updated.Steps)
+	}
+}
+
+func TestSetRecipeHouseholdRevision(t *testing.T) {
+	dbtest.Setup(t)
+	userID := dbtest.CreateUser(t, "cook").ID
+	household, err := CreateHousehold(types.CreateHousehold{Name: "Home"}, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipe, err := CreateRecipe(db.CreateRecipe{Title: "Pancakes"}, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetRecipeHousehold(recipe.ID, &household.ID, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	from, err := GetRecipeRevision(recipe.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := GetRecipeRevision(recipe.ID, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromRecipe := from.Recipe.Data()
+	changes, err := fromRecipe.Diff(to.Recipe.Data())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Field != "householdId" {
+		t.Errorf("changes = %+v, want the household", changes)
+	}
+}
@@ -0,0 +1,131 @@
+package crud
+
+import (
+	"github.com/google/uuid"
+	"github.com/my-cooking-codex/api/db"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Store the recipe as it currently is, as its next revision
+func createRecipeRevision(tx *gorm.DB, recipeID uuid.UUID, authorID uuid.UUID) error {
+	var recipe db.Recipe
+	if err := tx.Preload("Labels").First(&recipe, "id = ?", recipeID).Error; err != nil {
+		return err
+	}
+	var lastNumber uint
+	if err := tx.
+		Model(&db.RecipeRevision{}).
+		Select("COALESCE(MAX(number), 0)").
+		Where("recipe_id = ?", recipeID).
+		Scan(&lastNumber).
+		Error; err != nil {
+		return err
+	}
+	return tx.Create(&db.RecipeRevision{
+		RecipeID: recipeID,
+		Number:   lastNumber + 1,
+		AuthorID: authorID,
+		Recipe:   datatypes.NewJSONType(recipe.IntoReadRecipe()),
+	}).Error
+}
+
+// Recipes created before revisions existed have none,
+// so keep their current state as the first revision before it is changed
+func ensureRecipeRevision(tx *gorm.DB, recipeID uuid.UUID) error {
+	var count int64
+	if err := tx.Model(&db.RecipeRevision{}).Where("recipe_id = ?", recipeID).Count(&count).Error; err != nil {
+		return err
+	} else if count != 0 {
+		return nil
+	}
+	var recipe db.Recipe
+	if err := tx.Select("owner_id").First(&recipe, "id = ?", recipeID).Error; err != nil {
+		return err
+	}
+	return createRecipeRevision(tx, recipeID, recipe.OwnerID)
+}
+
+func GetRecipeRevisions(recipeID uuid.UUID) ([]db.ReadRecipeRevision, error) {
+	var revisions []db.ReadRecipeRevision
+	err := db.DB.
+		Model(&db.RecipeRevision{}).
+		Select("recipe_revisions.id, recipe_revisions.created_at, recipe_revisions.number, recipe_revisions.author_id, users.username AS author_username").
+		Joins("LEFT JOIN users ON recipe_revisions.author_id = users.id").
+		Where("recipe_revisions.recipe_id = ?", recipeID).
+		Order("recipe_revisions.number DESC").
+		Scan(&revisions).
+		Error
+	return revisions, err
+}
+
+func GetRecipeRevision(recipeID uuid.UUID, number uint) (db.RecipeRevision, error) {
+	var revision db.RecipeRevision
+	err := db.DB.First(&revision, "recipe_id = ? AND number = ?", recipeID, number).Error
+	return revision, err
+}
+
+// Put a recipe back to how it was at a revision, which is stored as a new revision.
+// The image is left as is, since replaced images are not kept, and so is the
+// household, as moving a recipe between households needs its own permission
+func RestoreRecipeRevision(recipeID uuid.UUID, number uint, authorID uuid.UUID) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		var revision db.RecipeRevision
+		if err := tx.First(&revision, "recipe_id = ? AND number = ?", recipeID, number).Error; err != nil {
+			return err
+		}
+		snapshot := revision.Recipe.Data()
+
+		restored := db.Recipe{
+			Title:            snapshot.Title,
+			Info:             snapshot.Info,
+			ShortDescription: snapshot.ShortDescription,
+			LongDescription:  snapshot.LongDescription,
+		}
+		if snapshot.Ingredients != nil {
+			if err := checkRecipeCycle(tx, recipeID, *snapshot.Ingredients); err != nil {
+				return err
+			}
+			ingredients := datatypes.NewJSONType(*snapshot.Ingredients)
+			restored.Ingredients = &ingredients
+		}
+		if snapshot.Steps != nil {
+			steps := datatypes.NewJSONType(*snapshot.Steps)
+			restored.Steps = &steps
+		}
+		if err := tx.
+			Model(&db.Recipe{}).
+			Where("id = ?", recipeID).
+			Select(
+				"title",
+				"info_yields",
+				"info_cook_time",
+				"info_prep_time",
+				"info_freezable",
+				"info_microwave_only",
+				"info_source",
+				"short_description",
+				"long_description",
+				"ingredients",
+				"steps",
+			).
+			Updates(&restored).
+			Error; err != nil {
+			return err
+		}
+
+		labels := make([]db.Label, len(snapshot.Labels))
+		for i, label := range snapshot.Labels {
+			labels[i] = db.Label{Name: label}
+			if err := tx.FirstOrCreate(&labels[i], "name = ?", label).Select("id").Error; err != nil {
+				return err
+			}
+		}
+		recipe := db.Recipe{UUIDBase: db.UUIDBase{ID: recipeID}}
+		if err := tx.Model(&recipe).Association("Labels").Replace(&labels); err != nil {
+			return err
+		}
+
+		return createRecipeRevision(tx, recipeID, authorID)
+	})
+}
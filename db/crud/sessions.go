@@ -0,0 +1,91 @@
+package crud
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/my-cooking-codex/api/db"
+)
+
+func CreateSession(userID uuid.UUID, refreshTokenHash string, expiresAt time.Time) (db.Session, error) {
+	session := db.Session{
+		UserID:           userID,
+		RefreshTokenHash: refreshTokenHash,
+		ExpiresAt:        expiresAt,
+	}
+	err := db.DB.Create(&session).Error
+	return session, err
+}
+
+func GetSessionById(sessionID uuid.UUID) (db.Session, error) {
+	var session db.Session
+	err := db.DB.First(&session, "id = ?", sessionID).Error
+	return session, err
+}
+
+func GetSessionByRefreshTokenHash(refreshTokenHash string) (db.Session, error) {
+	var session db.Session
+	err := db.DB.First(&session, "refresh_token_hash = ?", refreshTokenHash).Error
+	return session, err
+}
+
+func IsSessionActive(sessionID uuid.UUID) (bool, error) {
+	var count int64
+	err := db.DB.
+		Model(&db.Session{}).
+		Where("id = ? AND revoked_at IS NULL AND expires_at > ?", sessionID, time.Now()).
+		Count(&count).
+		Error
+	return count > 0, err
+}
+
+// Replace the refresh token of an active session,
+// returns false if the old token has already been used
+func RotateSessionRefreshToken(
+	sessionID uuid.UUID,
+	oldRefreshTokenHash string,
+	newRefreshTokenHash string,
+	expiresAt time.Time,
+) (bool, error) {
+	result := db.DB.
+		Model(&db.Session{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", sessionID, oldRefreshTokenHash).
+		Updates(map[string]any{
+			"refresh_token_hash": newRefreshTokenHash,
+			"expires_at":         expiresAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func RevokeSession(sessionID uuid.UUID) error {
+	return db.DB.
+		Model(&db.Session{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", time.Now()).
+		Error
+}
+
+func RevokeSessionsByUserID(userID uuid.UUID) error {
+	return db.DB.
+		Model(&db.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).
+		Error
+}
+
+// Revoke all of a user's sessions, apart from the one given
+func RevokeOtherSessionsByUserID(userID uuid.UUID, keepSessionID uuid.UUID) error {
+	return db.DB.
+		Model(&db.Session{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, keepSessionID).
+		Update("revoked_at", time.Now()).
+		Error
+}
+
+// Remove sessions that can no longer be used
+func DeleteStaleSessionsByUserID(userID uuid.UUID) error {
+	return db.DB.
+		Where("user_id = ? AND (revoked_at IS NOT NULL OR expires_at <= ?)", userID, time.Now()).
+		Delete(&db.Session{}).
+		Error
+}
@@ -0,0 +1,54 @@
+package crud
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/my-cooking-codex/api/core"
+	"github.com/my-cooking-codex/api/db"
+)
+
+func CreateRecipeShare(newShare db.CreateRecipeShare, recipeID uuid.UUID, userID uuid.UUID) (db.RecipeShare, error) {
+	token, _, err := core.CreateOpaqueToken()
+	if err != nil {
+		return db.RecipeShare{}, err
+	}
+	share := db.RecipeShare{
+		RecipeID:    recipeID,
+		CreatedByID: userID,
+		Token:       token,
+		ExpiresAt:   newShare.ExpiresAt,
+	}
+	err = db.DB.Create(&share).Error
+	return share, err
+}
+
+func GetRecipeSharesByRecipeID(recipeID uuid.UUID) ([]db.RecipeShare, error) {
+	var shares []db.RecipeShare
+	err := db.DB.Where("recipe_id = ?", recipeID).Order("created_at DESC").Find(&shares).Error
+	return shares, err
+}
+
+// Get a share by its token, only when it hasn't expired
+func GetActiveRecipeShareByToken(token string) (db.RecipeShare, error) {
+	var share db.RecipeShare
+	err := db.DB.
+		Where("token = ? AND (expires_at IS NULL OR expires_at > ?)", token, time.Now()).
+		First(&share).
+		Error
+	return share, err
+}
+
+func DoesRecipeHaveShare(recipeID uuid.UUID, shareID uuid.UUID) (bool, error) {
+	var count int64
+	err := db.DB.
+		Model(&db.RecipeShare{}).
+		Where("id = ? AND recipe_id = ?", shareID, recipeID).
+		Count(&count).
+		Error
+	return count > 0, err
+}
+
+func DeleteRecipeShare(shareID uuid.UUID) error {
+	return db.DB.Where("id = ?", shareID).Delete(&db.RecipeShare{}).Error
+}
@@ -0,0 +1,75 @@
+package crud
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/my-cooking-codex/api/db"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSubRecipeNotFound = errors.New("recipe used as an ingredient not found")
+	ErrRecipeCycle       = errors.New("recipe can't include itself")
+)
+
+func subRecipeIDs(ingredients []db.RecipeIngredient) []uuid.UUID {
+	var ids []uuid.UUID
+	for _, ingredient := range ingredients {
+		if ingredient.RecipeID != nil {
+			ids = append(ids, *ingredient.RecipeID)
+		}
+	}
+	return ids
+}
+
+// Check the recipes used as ingredients exist, naming
+// the ingredients after them when no name was given
+func fillSubRecipeNames(tx *gorm.DB, ingredients []db.RecipeIngredient) error {
+	for i, ingredient := range ingredients {
+		if ingredient.RecipeID == nil {
+			continue
+		}
+		var linked db.Recipe
+		if err := tx.Select("id", "title").First(&linked, "id = ?", ingredient.RecipeID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSubRecipeNotFound
+			}
+			return err
+		}
+		if strings.TrimSpace(ingredient.Name) == "" {
+			ingredients[i].Name = linked.Title
+		}
+	}
+	return nil
+}
+
+// Follow the recipes used as ingredients, and the ones they use,
+// making sure none of them lead back to the recipe
+func checkRecipeCycle(tx *gorm.DB, recipeID uuid.UUID, ingredients []db.RecipeIngredient) error {
+	pending := subRecipeIDs(ingredients)
+	visited := map[uuid.UUID]bool{}
+	for len(pending) != 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if id == recipeID {
+			return ErrRecipeCycle
+		} else if visited[id] {
+			continue
+		}
+		visited[id] = true
+
+		var linked db.Recipe
+		if err := tx.Select("id", "ingredients").First(&linked, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return err
+		}
+		if linked.Ingredients != nil {
+			pending = append(pending, subRecipeIDs(linked.Ingredients.Data())...)
+		}
+	}
+	return nil
+}
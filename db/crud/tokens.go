@@ -0,0 +1,66 @@
+package crud
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/my-cooking-codex/api/core"
+	"github.com/my-cooking-codex/api/db"
+)
+
+const APITokenPrefix = "mcc_"
+
+func CreateAPIToken(newToken db.CreateAPIToken, userID uuid.UUID) (db.CreatedAPIToken, error) {
+	rawToken, _, err := core.CreateOpaqueToken()
+	if err != nil {
+		return db.CreatedAPIToken{}, err
+	}
+	rawToken = APITokenPrefix + rawToken
+	apiToken := db.APIToken{
+		UserID:    userID,
+		Name:      newToken.Name,
+		TokenHash: core.HashOpaqueToken(rawToken),
+		Scope:     newToken.Scope,
+		ExpiresAt: newToken.ExpiresAt,
+	}
+	if err := db.DB.Create(&apiToken).Error; err != nil {
+		return db.CreatedAPIToken{}, err
+	}
+	return db.CreatedAPIToken{APIToken: apiToken, Token: rawToken}, nil
+}
+
+func GetAPITokensByUserID(userID uuid.UUID) ([]db.APIToken, error) {
+	var apiTokens []db.APIToken
+	err := db.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&apiTokens).Error
+	return apiTokens, err
+}
+
+func GetAPITokenByRawToken(rawToken string) (db.APIToken, error) {
+	var apiToken db.APIToken
+	err := db.DB.First(&apiToken, "token_hash = ?", core.HashOpaqueToken(rawToken)).Error
+	return apiToken, err
+}
+
+func DoesUserOwnAPIToken(userID uuid.UUID, tokenID uuid.UUID) (bool, error) {
+	var count int64
+	err := db.DB.
+		Model(&db.APIToken{}).
+		Where("id = ? AND user_id = ?", tokenID, userID).
+		Count(&count).
+		Error
+	return count > 0, err
+}
+
+// Record that a token has been used, at most once a minute to limit writes
+func TouchAPIToken(tokenID uuid.UUID) error {
+	now := time.Now()
+	return db.DB.
+		Model(&db.APIToken{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", tokenID, now.Add(-time.Minute)).
+		Update("last_used_at", now).
+		Error
+}
+
+func DeleteAPIToken(tokenID uuid.UUID) error {
+	return db.DB.Where("id = ?", tokenID).Delete(&db.APIToken{}).Error
+}
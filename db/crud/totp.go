@@ -0,0 +1,78 @@
+package crud
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/my-cooking-codex/api/core"
+	"github.com/my-cooking-codex/api/db"
+	"gorm.io/gorm"
+)
+
+// Store a secret that is waiting to be verified, before TOTP is enabled
+func SetUserPendingTOTPSecret(userID uuid.UUID, secret string) error {
+	defer userCache.Delete(userID)
+	return db.DB.
+		Model(&db.User{}).
+		Where("id = ? AND totp_enabled = ?", userID, false).
+		Update("totp_secret", secret).
+		Error
+}
+
+// Enable TOTP for a user, replacing any old recovery codes
+func EnableUserTOTP(userID uuid.UUID, counter uint64, recoveryCodes []string) error {
+	defer userCache.Delete(userID)
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&db.User{}).Where("id = ?", userID).Updates(map[string]any{
+			"totp_enabled":      true,
+			"totp_last_counter": counter,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&db.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]db.RecoveryCode, len(recoveryCodes))
+		for i, code := range recoveryCodes {
+			codes[i] = db.RecoveryCode{
+				UserID:   userID,
+				CodeHash: core.HashRecoveryCode(code),
+			}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func DisableUserTOTP(userID uuid.UUID) error {
+	defer userCache.Delete(userID)
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&db.User{}).Where("id = ?", userID).Updates(map[string]any{
+			"totp_enabled":      false,
+			"totp_secret":       nil,
+			"totp_last_counter": 0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&db.RecoveryCode{}).Error
+	})
+}
+
+// Record the counter of a used TOTP code,
+// returns false if it or a later code was already used
+func UseUserTOTPCounter(userID uuid.UUID, counter uint64) (bool, error) {
+	defer userCache.Delete(userID)
+	result := db.DB.
+		Model(&db.User{}).
+		Where("id = ? AND totp_last_counter < ?", userID, counter).
+		Update("totp_last_counter", counter)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Mark a recovery code as used, returns false if it is not valid
+func UseRecoveryCode(userID uuid.UUID, code string) (bool, error) {
+	result := db.DB.
+		Model(&db.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, core.HashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
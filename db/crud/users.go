@@ -1,13 +1,59 @@
 package crud
 
 import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/google/uuid"
+	"github.com/my-cooking-codex/api/config"
+	"github.com/my-cooking-codex/api/core"
 	"github.com/my-cooking-codex/api/db"
+	"gorm.io/gorm"
+)
+
+var ErrRegistrationClosed = errors.New("registration is closed")
+
+// Recently loaded users, so authenticating a request doesn't always need a query
+var userCache = core.NewTTLCache[uuid.UUID, db.User](30 * time.Second)
+
+// Failed logins for usernames that don't exist, so they are locked out
+// like real accounts and a lockout doesn't give away which ones exist.
+// Bounded, as anyone can add to it, and forgotten once a lockout ends
+var unknownUsers = core.NewBoundedTTLCache[string, db.User](24*time.Hour, 10000)
+
+// Compared against when logging in as a user that doesn't exist,
+// so it takes as long as for one that does
+var (
+	unknownUserPasswordHash     []byte
+	unknownUserPasswordHashOnce sync.Once
 )
 
-func CreateUser(user db.CreateUser) (db.User, error) {
+// Create a new user, following the registration mode.
+// The first user can always register and is made an admin
+func CreateUser(user db.CreateUser, mode config.RegistrationMode) (db.User, error) {
 	var newUser = user.IntoUser()
-	if err := db.DB.Create(&newUser).Error; err != nil {
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&db.User{}).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			newUser.IsAdmin = true
+		} else if mode == config.RegistrationClosed {
+			return ErrRegistrationClosed
+		} else if mode == config.RegistrationInvite {
+			if user.InviteCode == nil {
+				return ErrInviteCodeInvalid
+			}
+			if err := redeemInviteCode(tx, *user.InviteCode); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&newUser).Error
+	})
+	if err != nil {
 		return db.User{}, err
 	}
 	return newUser, nil
@@ -21,6 +67,19 @@ func GetUserById(userID uuid.UUID) (db.User, error) {
 	return user, nil
 }
 
+// Get a user, which may be up to a few seconds old
+func GetUserByIdCached(userID uuid.UUID) (db.User, error) {
+	if user, ok := userCache.Get(userID); ok {
+		return user, nil
+	}
+	user, err := GetUserById(userID)
+	if err != nil {
+		return db.User{}, err
+	}
+	userCache.Set(userID, user)
+	return user, nil
+}
+
 func GetUserByUsername(username string) (db.User, error) {
 	var user db.User
 	if err := db.DB.First(&user, "username = ?", username).Error; err != nil {
@@ -29,6 +88,17 @@ func GetUserByUsername(username string) (db.User, error) {
 	return user, nil
 }
 
+func GetUsers(offset uint, limit uint) ([]db.User, error) {
+	var users []db.User
+	err := db.DB.
+		Offset(int(offset)).
+		Limit(int(limit)).
+		Order("created_at ASC").
+		Find(&users).
+		Error
+	return users, err
+}
+
 func GetUserCount() (int64, error) {
 	var count int64
 	if err := db.DB.Model(&db.User{}).Count(&count).Error; err != nil {
@@ -36,3 +106,215 @@ func GetUserCount() (int64, error) {
 	}
 	return count, nil
 }
+
+// Promote the oldest user to admin, when there are users but no admins.
+// Returns the promoted user's username, or nil if nothing changed
+func EnsureAdminExists() (*string, error) {
+	var adminCount int64
+	if err := db.DB.Model(&db.User{}).Where("is_admin = ?", true).Count(&adminCount).Error; err != nil {
+		return nil, err
+	} else if adminCount != 0 {
+		return nil, nil
+	}
+	var user db.User
+	if err := db.DB.Order("created_at ASC").Limit(1).Find(&user).Error; err != nil {
+		return nil, err
+	} else if user.ID == (uuid.UUID{}) {
+		return nil, nil
+	}
+	if err := SetUserAdmin(user.ID, true); err != nil {
+		return nil, err
+	}
+	return &user.Username, nil
+}
+
+func SetUserAdmin(userID uuid.UUID, isAdmin bool) error {
+	defer userCache.Delete(userID)
+	return db.DB.Model(&db.User{}).Where("id = ?", userID).Update("is_admin", isAdmin).Error
+}
+
+func SetUserDisabled(userID uuid.UUID, isDisabled bool) error {
+	defer userCache.Delete(userID)
+	return db.DB.Model(&db.User{}).Where("id = ?", userID).Update("is_disabled", isDisabled).Error
+}
+
+// How long to lock a user out for once they reach the threshold of failed
+// logins. Each failure past the threshold doubles the lockout, up to the max
+func lockoutDuration(
+	failedLogins uint,
+	threshold uint,
+	baseLockout time.Duration,
+	maxLockout time.Duration,
+) (time.Duration, bool) {
+	if threshold == 0 || failedLogins < threshold {
+		return 0, false
+	}
+	lockout := maxLockout
+	if exponent := failedLogins - threshold; exponent < 32 {
+		if backoff := baseLockout * (1 << exponent); backoff > 0 && backoff < maxLockout {
+			lockout = backoff
+		}
+	}
+	return lockout, true
+}
+
+// Count a failed login, locking the user out once the threshold is reached
+func RecordFailedLogin(
+	userID uuid.UUID,
+	threshold uint,
+	baseLockout time.Duration,
+	maxLockout time.Duration,
+) error {
+	defer userCache.Delete(userID)
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		var user db.User
+		if err := tx.
+			Model(&user).
+			Where("id = ?", userID).
+			Update("failed_logins", gorm.Expr("failed_logins + 1")).
+			Error; err != nil {
+			return err
+		}
+		if err := tx.Select("failed_logins").First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		lockout, ok := lockoutDuration(user.FailedLogins, threshold, baseLockout, maxLockout)
+		if !ok {
+			return nil
+		}
+		return tx.
+			Model(&db.User{}).
+			Where("id = ?", userID).
+			Update("locked_until", time.Now().Add(lockout)).
+			Error
+	})
+}
+
+// Get a stand-in for a username that doesn't exist, with its failed logins
+// and a password that never matches
+func GetUnknownUser(username string) db.User {
+	user, _ := unknownUsers.Get(strings.ToLower(username))
+	user.Username = username
+	unknownUserPasswordHashOnce.Do(func() {
+		var unknownUser db.User
+		unknownUser.SetPassword("unknown user")
+		unknownUserPasswordHash = unknownUser.HashedPassword
+	})
+	user.HashedPassword = unknownUserPasswordHash
+	return user
+}
+
+// Count a failed login for a username that doesn't exist, the same as RecordFailedLogin
+func RecordUnknownUserFailedLogin(
+	username string,
+	threshold uint,
+	baseLockout time.Duration,
+	maxLockout time.Duration,
+) {
+	key := strings.ToLower(username)
+	user, _ := unknownUsers.Get(key)
+	user.FailedLogins++
+	if lockout, ok := lockoutDuration(user.FailedLogins, threshold, baseLockout, maxLockout); ok {
+		lockedUntil := time.Now().Add(lockout)
+		user.LockedUntil = &lockedUntil
+		unknownUsers.SetUntil(key, user, lockedUntil)
+		return
+	}
+	unknownUsers.Set(key, user)
+}
+
+func ResetFailedLogins(userID uuid.UUID) error {
+	defer userCache.Delete(userID)
+	return db.DB.
+		Model(&db.User{}).
+		Where("id = ? AND (failed_logins <> 0 OR locked_until IS NOT NULL)", userID).
+		Updates(map[string]any{"failed_logins": 0, "locked_until": nil}).
+		Error
+}
+
+func UpdateUsername(userID uuid.UUID, username string) error {
+	defer userCache.Delete(userID)
+	return db.DB.Model(&db.User{}).Where("id = ?", userID).Update("username", username).Error
+}
+
+func UpdateUserPassword(userID uuid.UUID, newPlainPassword string) error {
+	defer userCache.Delete(userID)
+	var user db.User
+	user.SetPassword(newPlainPassword)
+	return db.DB.
+		Model(&db.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]any{"hashed_password": user.HashedPassword, "identity_only": false}).
+		Error
+}
+
+// Delete a user and everything they own,
+// returns the recipe image ids that should be removed from storage
+func DeleteUser(userID uuid.UUID) ([]uuid.UUID, error) {
+	defer userCache.Delete(userID)
+	var imageIDs []uuid.UUID
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Model(&db.Recipe{}).
+			Where("owner_id = ? AND image_id IS NOT NULL", userID).
+			Pluck("image_id", &imageIDs).
+			Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(
+			"DELETE FROM recipe_labels WHERE recipe_id IN (SELECT id FROM recipes WHERE owner_id = ?)",
+			userID,
+		).Error; err != nil {
+			return err
+		}
+		if err := tx.Where(
+			"created_by_id = ? OR recipe_id IN (SELECT id FROM recipes WHERE owner_id = ?)",
+			userID,
+			userID,
+		).Delete(&db.RecipeShare{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where(
+			"recipe_id IN (SELECT id FROM recipes WHERE owner_id = ?)",
+			userID,
+		).Delete(&db.RecipeRevision{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("owner_id = ?", userID).Delete(&db.Recipe{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`DELETE FROM pantry_item_labels WHERE pantry_item_id IN (
+    SELECT pi.id FROM pantry_items pi
+    JOIN pantry_locations pl ON pi.location_id = pl.id
+    WHERE pl.owner_id = ?
+)`, userID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(
+			"DELETE FROM pantry_items WHERE location_id IN (SELECT id FROM pantry_locations WHERE owner_id = ?)",
+			userID,
+		).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("owner_id = ?", userID).Delete(&db.PantryLocation{}).Error; err != nil {
+			return err
+		}
+		if err := leaveAllHouseholds(tx, userID); err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&db.Session{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&db.APIToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&db.UserIdentity{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&db.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", userID).Delete(&db.User{}).Error
+	})
+	return imageIDs, err
+}
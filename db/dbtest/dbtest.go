@@ -0,0 +1,35 @@
+// Helpers for tests that need a database
+package dbtest
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/my-cooking-codex/api/config"
+	"github.com/my-cooking-codex/api/db"
+)
+
+// Open a new in-memory database for a test, closed when it finishes
+func Setup(t testing.TB) {
+	t.Helper()
+	uri := "file:" + uuid.NewString() + "?mode=memory&cache=shared"
+	if err := db.InitDB(config.DBConfig{Type: "sqlite", URI: uri}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+}
+
+// Create a user whose password is "password"
+func CreateUser(t testing.TB, username string) db.User {
+	t.Helper()
+	newUser := db.CreateUser{Username: username, Password: "password"}
+	user := newUser.IntoUser()
+	if err := db.DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
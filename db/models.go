@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/my-cooking-codex/api/core"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
@@ -23,11 +24,20 @@ func (base *UUIDBase) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
+// IdentityOnly users were created through an identity provider and have never set a password
 type User struct {
 	UUIDBase
 	TimeBase
 	Username        string           `gorm:"uniqueIndex;not null;type:varchar(30)" json:"username"`
 	HashedPassword  []byte           `gorm:"not null" json:"-"`
+	IsAdmin         bool             `gorm:"not null;default:false" json:"isAdmin"`
+	IsDisabled      bool             `gorm:"not null;default:false" json:"isDisabled"`
+	TOTPSecret      *string          `json:"-"`
+	TOTPEnabled     bool             `gorm:"not null;default:false" json:"totpEnabled"`
+	TOTPLastCounter uint64           `gorm:"not null;default:0" json:"-"`
+	FailedLogins    uint             `gorm:"not null;default:0" json:"-"`
+	LockedUntil     *time.Time       `json:"-"`
+	IdentityOnly    bool             `gorm:"not null;default:false" json:"identityOnly"`
 	Recipes         []Recipe         `gorm:"foreignKey:OwnerID" json:"-"`
 	PantryLocations []PantryLocation `gorm:"foreignKey:OwnerId" json:"-"`
 }
@@ -40,6 +50,11 @@ func (u *User) SetPassword(newPlainPassword string) {
 	u.HashedPassword = hashedPw
 }
 
+// Whether too many failed logins mean the user must wait before trying again
+func (u *User) IsLockedOut() bool {
+	return u.LockedUntil != nil && u.LockedUntil.After(time.Now())
+}
+
 func (u *User) IsPasswordMatch(plainPassword string) bool {
 	if err := bcrypt.CompareHashAndPassword(u.HashedPassword, []byte(plainPassword)); err == nil {
 		return true
@@ -47,15 +62,109 @@ func (u *User) IsPasswordMatch(plainPassword string) bool {
 	return false
 }
 
+type Session struct {
+	UUIDBase
+	TimeBase
+	UserID           uuid.UUID  `gorm:"not null;type:uuid;index" json:"userId"`
+	RefreshTokenHash string     `gorm:"uniqueIndex;not null;type:varchar(64)" json:"-"`
+	ExpiresAt        time.Time  `gorm:"not null" json:"expiresAt"`
+	RevokedAt        *time.Time `json:"revokedAt,omitempty"`
+}
+
+// Whether the session can still be used
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil && s.ExpiresAt.After(time.Now())
+}
+
+// Single use code, for logging in when the TOTP device is unavailable
+type RecoveryCode struct {
+	UUIDBase
+	TimeBase
+	UserID   uuid.UUID `gorm:"not null;type:uuid;index"`
+	CodeHash string    `gorm:"not null;type:varchar(64)"`
+	UsedAt   *time.Time
+}
+
+// Links a user to an account at an external identity provider
+type UserIdentity struct {
+	UUIDBase
+	TimeBase
+	UserID  uuid.UUID `gorm:"not null;type:uuid;index" json:"-"`
+	Issuer  string    `gorm:"not null;uniqueIndex:idx_user_identities_issuer_subject" json:"issuer"`
+	Subject string    `gorm:"not null;uniqueIndex:idx_user_identities_issuer_subject" json:"subject"`
+}
+
+// A pending login with an external identity provider
+type OIDCLoginRequest struct {
+	UUIDBase
+	TimeBase
+	State        string     `gorm:"uniqueIndex;not null;type:varchar(64)"`
+	Nonce        string     `gorm:"not null"`
+	CodeVerifier string     `gorm:"not null"`
+	LinkUserID   *uuid.UUID `gorm:"type:uuid"`
+	ExpiresAt    time.Time  `gorm:"not null"`
+}
+
+type APIToken struct {
+	UUIDBase
+	TimeBase
+	UserID     uuid.UUID       `gorm:"not null;type:uuid;index" json:"-"`
+	Name       string          `gorm:"not null;size:60" json:"name"`
+	TokenHash  string          `gorm:"uniqueIndex;not null;type:varchar(64)" json:"-"`
+	Scope      core.TokenScope `gorm:"not null;type:varchar(20)" json:"scope"`
+	ExpiresAt  *time.Time      `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time      `json:"lastUsedAt,omitempty"`
+}
+
+// Whether the token can still be used
+func (t *APIToken) IsActive() bool {
+	return t.ExpiresAt == nil || t.ExpiresAt.After(time.Now())
+}
+
+type InviteCode struct {
+	UUIDBase
+	TimeBase
+	Code        string     `gorm:"uniqueIndex;not null;type:varchar(64)" json:"code"`
+	CreatedByID uuid.UUID  `gorm:"not null;type:uuid" json:"createdById"`
+	MaxUses     uint       `gorm:"not null;default:1" json:"maxUses"`
+	Uses        uint       `gorm:"not null;default:0" json:"uses"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+}
+
 type Label struct {
 	ID   uint   `gorm:"primarykey" json:"-"`
 	Name string `gorm:"uniqueIndex;not null;type:varchar(60);<-:create" json:"name"`
 }
 
+type HouseholdRole string
+
+const (
+	HouseholdOwner  HouseholdRole = "owner"
+	HouseholdEditor HouseholdRole = "editor"
+	HouseholdViewer HouseholdRole = "viewer"
+)
+
+// A group of users sharing recipes & pantry locations
+type Household struct {
+	UUIDBase
+	TimeBase
+	Name    string            `gorm:"not null;size:60" json:"name"`
+	Members []HouseholdMember `gorm:"foreignKey:HouseholdID" json:"-"`
+}
+
+type HouseholdMember struct {
+	TimeBase
+	HouseholdID uuid.UUID     `gorm:"primarykey;type:uuid" json:"householdId"`
+	UserID      uuid.UUID     `gorm:"primarykey;type:uuid;index" json:"userId"`
+	Role        HouseholdRole `gorm:"not null;type:varchar(10)" json:"role"`
+	User        User          `json:"-"`
+}
+
 type Recipe struct {
 	UUIDBase
 	TimeBase
 	OwnerID          uuid.UUID                               `gorm:"not null;type:uuid" json:"ownerId"`
+	HouseholdID      *uuid.UUID                              `gorm:"type:uuid;index" json:"householdId"`
 	Title            string                                  `gorm:"not null;type:varchar(60)" json:"title"`
 	Info             RecipeInfo                              `gorm:"embedded;embeddedPrefix:info_" json:"info"`
 	ShortDescription *string                                 `gorm:"type:varchar(256)" json:"shortDescription,omitempty"`
@@ -63,6 +172,7 @@ type Recipe struct {
 	Ingredients      *datatypes.JSONType[[]RecipeIngredient] `gorm:"type:json" json:"ingredients,omitempty"`
 	Steps            *datatypes.JSONType[[]RecipeStep]       `gorm:"type:json" json:"steps,omitempty"`
 	ImageID          *uuid.UUID                              `gorm:"type:uuid" json:"imageId"`
+	ForkedFromID     *uuid.UUID                              `gorm:"type:uuid" json:"forkedFromId"`
 	Labels           []Label                                 `gorm:"many2many:recipe_labels" json:"-"`
 }
 
@@ -71,6 +181,7 @@ func (r *Recipe) IntoReadRecipe() ReadRecipe {
 		UUIDBase:         r.UUIDBase,
 		TimeBase:         r.TimeBase,
 		OwnerID:          r.OwnerID,
+		HouseholdID:      r.HouseholdID,
 		Title:            r.Title,
 		Info:             r.Info,
 		ShortDescription: r.ShortDescription,
@@ -89,7 +200,8 @@ func (r *Recipe) IntoReadRecipe() ReadRecipe {
 			s := r.Steps.Data()
 			return &s
 		}(),
-		ImageID: r.ImageID,
+		ImageID:      r.ImageID,
+		ForkedFromID: r.ForkedFromID,
 		Labels: func() []string {
 			labels := make([]string, len(r.Labels))
 			for i, label := range r.Labels {
@@ -100,12 +212,33 @@ func (r *Recipe) IntoReadRecipe() ReadRecipe {
 	}
 }
 
+// An immutable snapshot of a recipe, taken whenever it changes
+type RecipeRevision struct {
+	UUIDBase
+	TimeBase
+	RecipeID uuid.UUID                      `gorm:"not null;type:uuid;uniqueIndex:idx_recipe_revisions_recipe_number" json:"recipeId"`
+	Number   uint                           `gorm:"not null;uniqueIndex:idx_recipe_revisions_recipe_number" json:"number"`
+	AuthorID uuid.UUID                      `gorm:"not null;type:uuid" json:"authorId"`
+	Recipe   datatypes.JSONType[ReadRecipe] `gorm:"type:json" json:"recipe"`
+}
+
+// A public, read-only link to a recipe
+type RecipeShare struct {
+	UUIDBase
+	TimeBase
+	RecipeID    uuid.UUID  `gorm:"not null;type:uuid;index" json:"recipeId"`
+	CreatedByID uuid.UUID  `gorm:"not null;type:uuid" json:"createdById"`
+	Token       string     `gorm:"not null;uniqueIndex;size:64" json:"token"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+}
+
 type PantryLocation struct {
 	UUIDBase
 	TimeBase
-	Name    string       `gorm:"not null;size:60" json:"name"`
-	OwnerId uuid.UUID    `gorm:"not null;type:uuid" json:"ownerId"`
-	Items   []PantryItem `gorm:"foreignKey:LocationId" json:"-"`
+	Name        string       `gorm:"not null;size:60" json:"name"`
+	OwnerId     uuid.UUID    `gorm:"not null;type:uuid" json:"ownerId"`
+	HouseholdID *uuid.UUID   `gorm:"type:uuid;index" json:"householdId"`
+	Items       []PantryItem `gorm:"foreignKey:LocationId" json:"-"`
 }
 
 type PantryItem struct {
@@ -1,21 +1,124 @@
 package db
 
 import (
+	"bytes"
+	"encoding/json"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/my-cooking-codex/api/core"
 	"gorm.io/datatypes"
 )
 
+// Nutritional values, calories in kcal, sodium in mg and the rest in grams
+type Nutrition struct {
+	Calories      float64 `json:"calories"`
+	Protein       float64 `json:"protein"`
+	Fat           float64 `json:"fat"`
+	Carbohydrates float64 `json:"carbohydrates"`
+	Fiber         float64 `json:"fiber"`
+	Sodium        float64 `json:"sodium"`
+}
+
+func (n Nutrition) Add(other Nutrition) Nutrition {
+	return Nutrition{
+		Calories:      n.Calories + other.Calories,
+		Protein:       n.Protein + other.Protein,
+		Fat:           n.Fat + other.Fat,
+		Carbohydrates: n.Carbohydrates + other.Carbohydrates,
+		Fiber:         n.Fiber + other.Fiber,
+		Sodium:        n.Sodium + other.Sodium,
+	}
+}
+
+func (n Nutrition) Scale(factor float64) Nutrition {
+	return Nutrition{
+		Calories:      n.Calories * factor,
+		Protein:       n.Protein * factor,
+		Fat:           n.Fat * factor,
+		Carbohydrates: n.Carbohydrates * factor,
+		Fiber:         n.Fiber * factor,
+		Sodium:        n.Sodium * factor,
+	}
+}
+
+func (n Nutrition) IsValid() bool {
+	return n.Calories >= 0 && n.Protein >= 0 && n.Fat >= 0 && n.Carbohydrates >= 0 && n.Fiber >= 0 && n.Sodium >= 0
+}
+
+// An ingredient, which can be another recipe by setting RecipeID,
+// measured in the other recipe's yield unit or in batches of it.
+// Nutrition is for the amount given, used instead of an estimate
 type RecipeIngredient struct {
-	Name        string  `json:"name" validate:"required"`
-	Amount      float32 `json:"amount" validate:"required"`
-	UnitType    string  `json:"unitType" validate:"required"`
-	Description *string `json:"description,omitempty"`
+	Name        string     `json:"name" validate:"required"`
+	Amount      float32    `json:"amount" validate:"required"`
+	UnitType    string     `json:"unitType" validate:"required"`
+	Description *string    `json:"description,omitempty"`
+	Section     *string    `json:"section,omitempty"`
+	RecipeID    *uuid.UUID `json:"recipeId,omitempty"`
+	Nutrition   *Nutrition `json:"nutrition,omitempty"`
+}
+
+type StepTimer struct {
+	Seconds uint    `json:"seconds" validate:"required,gt=0,lte=604800"`
+	Label   *string `json:"label,omitempty" validate:"omitempty,max=60"`
+	// found in the step's description rather than given
+	Detected bool `json:"detected,omitempty"`
+}
+
+type StepTemperature struct {
+	Value float32 `json:"value" validate:"gte=-100,lte=1000"`
+	Unit  string  `json:"unit" validate:"required,oneof=C F"`
 }
 
+// A step of a recipe, IngredientIndexes being the
+// positions of the recipe's ingredients the step uses
 type RecipeStep struct {
-	Title       *string `json:"title,omitempty"`
-	Description string  `json:"description" validate:"required"`
+	Title             *string          `json:"title,omitempty"`
+	Description       string           `json:"description" validate:"required"`
+	Section           *string          `json:"section,omitempty"`
+	Timers            []StepTimer      `json:"timers,omitempty" validate:"omitempty,max=20,dive"`
+	Temperature       *StepTemperature `json:"temperature,omitempty"`
+	IngredientIndexes []uint           `json:"ingredientIndexes,omitempty"`
+}
+
+// Add timers for the durations written in a step's description, when it wasn't given any
+func detectStepTimers(steps []RecipeStep) []RecipeStep {
+	detected := make([]RecipeStep, len(steps))
+	for i, step := range steps {
+		if len(step.Timers) == 0 {
+			for _, duration := range core.FindDurations(step.Description) {
+				label := duration.Text
+				step.Timers = append(step.Timers, StepTimer{
+					Seconds:  uint(duration.Duration.Seconds()),
+					Label:    &label,
+					Detected: true,
+				})
+			}
+		}
+		detected[i] = step
+	}
+	return detected
+}
+
+// A recipe used as an ingredient, scaled to the amount used. Path holds the
+// indexes of the ingredients leading to it, starting from the outer recipe
+type SubRecipe struct {
+	RecipeID    uuid.UUID          `json:"recipeId"`
+	Path        []uint             `json:"path"`
+	Title       string             `json:"title"`
+	Scale       float64            `json:"scale"`
+	Ingredients []RecipeIngredient `json:"ingredients"`
+	Steps       []RecipeStep       `json:"steps"`
+}
+
+// Nutrition estimated from a recipe's ingredients, per serving when the recipe
+// has yields. Unmatched lists the indexes of ingredients that couldn't be
+// estimated, or only partly for recipes used as ingredients
+type RecipeNutrition struct {
+	Total      Nutrition  `json:"total"`
+	PerServing *Nutrition `json:"perServing,omitempty"`
+	Unmatched  []uint     `json:"unmatched"`
 }
 
 type RecipeInfoYields struct {
@@ -33,8 +136,9 @@ type RecipeInfo struct {
 }
 
 type CreateUser struct {
-	Username string `json:"username" validate:"required,alphanum,min=3,max=30"`
-	Password string `json:"password" validate:"required"`
+	Username   string  `json:"username" validate:"required,alphanum,min=3,max=30"`
+	Password   string  `json:"password" validate:"required"`
+	InviteCode *string `json:"inviteCode,omitempty"`
 }
 
 func (u *CreateUser) IntoUser() User {
@@ -45,6 +149,61 @@ func (u *CreateUser) IntoUser() User {
 	return user
 }
 
+type UpdateUser struct {
+	CurrentPassword string  `json:"currentPassword"`
+	Username        *string `json:"username,omitempty" validate:"omitempty,alphanum,min=3,max=30"`
+	Password        *string `json:"password,omitempty" validate:"omitempty,min=1"`
+}
+
+type DeleteUser struct {
+	Password string `json:"password"`
+}
+
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type VerifyTOTP struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type DisableTOTP struct {
+	Password string `json:"password"`
+}
+
+type TOTPRecoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type ResetUserPassword struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type CreateOIDCLogin struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+	// needed when a new account is created with the invite registration mode
+	InviteCode *string `json:"inviteCode,omitempty"`
+}
+
+type CreateAPIToken struct {
+	Name      string          `json:"name" validate:"required,min=1,max=60"`
+	Scope     core.TokenScope `json:"scope" validate:"required,oneof=read read-write"`
+	ExpiresAt *time.Time      `json:"expiresAt,omitempty"`
+}
+
+// Returned only once, when the token is created
+type CreatedAPIToken struct {
+	APIToken
+	Token string `json:"token"`
+}
+
+type CreateInviteCode struct {
+	MaxUses   uint       `json:"maxUses" validate:"omitempty,gt=0"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
 type CreateRecipeInfo RecipeInfo
 
 type CreateRecipe struct {
@@ -53,25 +212,179 @@ type CreateRecipe struct {
 	ShortDescription *string            `json:"shortDescription,omitempty" validate:"omitempty,max=256"`
 	LongDescription  *string            `json:"longDescription,omitempty"`
 	Ingredients      []RecipeIngredient `json:"ingredients,omitempty"`
-	Steps            []RecipeStep       `json:"steps,omitempty"`
+	IngredientsText  *string            `json:"ingredientsText,omitempty" validate:"omitempty,max=20000"`
+	Steps            []RecipeStep       `json:"steps,omitempty" validate:"omitempty,dive"`
 	Labels           []string           `json:"labels,omitempty" validate:"dive,min=1,max=60"`
+	HouseholdID      *uuid.UUID         `json:"householdId,omitempty"`
 }
 
 func (r *CreateRecipe) IntoRecipe(ownerID uuid.UUID, imageID *uuid.UUID) Recipe {
-	return Recipe{
+	recipe := Recipe{
 		OwnerID:          ownerID,
+		HouseholdID:      r.HouseholdID,
 		Title:            r.Title,
 		Info:             RecipeInfo(r.Info),
 		ShortDescription: r.ShortDescription,
 		LongDescription:  r.LongDescription,
 		ImageID:          imageID,
 	}
+	if len(r.Ingredients) != 0 {
+		ingredients := datatypes.NewJSONType(r.Ingredients)
+		recipe.Ingredients = &ingredients
+	}
+	if len(r.Steps) != 0 {
+		steps := datatypes.NewJSONType(r.Steps)
+		recipe.Steps = &steps
+	}
+	return recipe
+}
+
+type ParseIngredients struct {
+	Text string `json:"text" validate:"required,max=20000"`
+}
+
+type ImportRecipeURL struct {
+	URL         string     `json:"url" validate:"required,http_url"`
+	HouseholdID *uuid.UUID `json:"householdId,omitempty"`
+}
+
+type ImportRecipeArchive struct {
+	Format      string     `form:"format" validate:"required,oneof=paprika mealie tandoor cooklang"`
+	HouseholdID *uuid.UUID `form:"householdId"`
+}
+
+type ImportReportEntry struct {
+	Name     string     `json:"name"`
+	RecipeID *uuid.UUID `json:"recipeId,omitempty"`
+	Reason   string     `json:"reason,omitempty"`
+}
+
+// What happened to each recipe in an import
+type ImportReport struct {
+	Created []ImportReportEntry `json:"created"`
+	Skipped []ImportReportEntry `json:"skipped"`
+	Failed  []ImportReportEntry `json:"failed"`
 }
 
 type ReadRecipe struct {
 	UUIDBase
 	TimeBase
 	OwnerID          uuid.UUID           `json:"ownerId"`
+	HouseholdID      *uuid.UUID          `json:"householdId"`
+	Title            string              `json:"title"`
+	Info             RecipeInfo          `json:"info"`
+	ShortDescription *string             `json:"shortDescription,omitempty"`
+	LongDescription  *string             `json:"longDescription,omitempty"`
+	Ingredients      *[]RecipeIngredient `json:"ingredients,omitempty"`
+	Steps            *[]RecipeStep       `json:"steps,omitempty"`
+	ImageID          *uuid.UUID          `json:"imageId"`
+	ForkedFromID     *uuid.UUID          `json:"forkedFromId"`
+	Labels           []string            `json:"labels"`
+	SubRecipes       []SubRecipe         `json:"subRecipes,omitempty"`
+	Nutrition        *RecipeNutrition    `json:"nutrition,omitempty"`
+}
+
+// Add timers to steps from the durations written in them, for steps not given any
+func (r *ReadRecipe) DetectStepTimers() {
+	if r.Steps != nil {
+		steps := detectStepTimers(*r.Steps)
+		r.Steps = &steps
+	}
+	for i := range r.SubRecipes {
+		r.SubRecipes[i].Steps = detectStepTimers(r.SubRecipes[i].Steps)
+	}
+}
+
+func (r *ReadRecipe) IntoCreateRecipe() CreateRecipe {
+	recipe := CreateRecipe{
+		Title:            r.Title,
+		Info:             CreateRecipeInfo(r.Info),
+		ShortDescription: r.ShortDescription,
+		LongDescription:  r.LongDescription,
+		Labels:           r.Labels,
+		HouseholdID:      r.HouseholdID,
+	}
+	if r.Ingredients != nil {
+		recipe.Ingredients = *r.Ingredients
+	}
+	if r.Steps != nil {
+		recipe.Steps = *r.Steps
+	}
+	return recipe
+}
+
+type ReadRecipeRevision struct {
+	UUIDBase
+	CreatedAt      time.Time `json:"createdAt"`
+	Number         uint      `json:"number"`
+	AuthorID       uuid.UUID `json:"authorId"`
+	AuthorUsername *string   `json:"authorUsername"`
+}
+
+// A field that differs between two versions of a recipe
+type RecipeFieldChange struct {
+	Field string          `json:"field"`
+	From  json.RawMessage `json:"from"`
+	To    json.RawMessage `json:"to"`
+}
+
+// Fields that are compared between versions, in the order changes are reported
+var recipeDiffFields = []string{
+	"title",
+	"info",
+	"shortDescription",
+	"longDescription",
+	"ingredients",
+	"steps",
+	"imageId",
+	"householdId",
+	"labels",
+}
+
+// Get a recipe's JSON fields, so versions can be compared field by field
+func recipeFields(recipe ReadRecipe) (map[string]json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	raw, err := json.Marshal(recipe)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// Get the fields that changed between this and a newer version of the recipe
+func (r *ReadRecipe) Diff(newer ReadRecipe) ([]RecipeFieldChange, error) {
+	oldFields, err := recipeFields(*r)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := recipeFields(newer)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]RecipeFieldChange, 0)
+	for _, field := range recipeDiffFields {
+		from, to := oldFields[field], newFields[field]
+		if from == nil {
+			from = json.RawMessage("null")
+		}
+		if to == nil {
+			to = json.RawMessage("null")
+		}
+		if !bytes.Equal(from, to) {
+			changes = append(changes, RecipeFieldChange{Field: field, From: from, To: to})
+		}
+	}
+	return changes, nil
+}
+
+// A recipe as seen through a share link, without any owner details
+type ReadSharedRecipe struct {
+	UUIDBase
+	TimeBase
 	Title            string              `json:"title"`
 	Info             RecipeInfo          `json:"info"`
 	ShortDescription *string             `json:"shortDescription,omitempty"`
@@ -80,18 +393,48 @@ type ReadRecipe struct {
 	Steps            *[]RecipeStep       `json:"steps,omitempty"`
 	ImageID          *uuid.UUID          `json:"imageId"`
 	Labels           []string            `json:"labels"`
+	SubRecipes       []SubRecipe         `json:"subRecipes,omitempty"`
+	Nutrition        *RecipeNutrition    `json:"nutrition,omitempty"`
+}
+
+func (r *ReadRecipe) IntoReadSharedRecipe() ReadSharedRecipe {
+	return ReadSharedRecipe{
+		UUIDBase:         r.UUIDBase,
+		TimeBase:         r.TimeBase,
+		Title:            r.Title,
+		Info:             r.Info,
+		ShortDescription: r.ShortDescription,
+		LongDescription:  r.LongDescription,
+		Ingredients:      r.Ingredients,
+		Steps:            r.Steps,
+		ImageID:          r.ImageID,
+		Labels:           r.Labels,
+		SubRecipes:       r.SubRecipes,
+		Nutrition:        r.Nutrition,
+	}
+}
+
+type CreateRecipeShare struct {
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
 }
 
 type UpdateIngredient struct {
-	Name        string  `json:"name,omitempty"`
-	Amount      float32 `json:"amount,omitempty"`
-	UnitType    string  `json:"unitType,omitempty"`
-	Description *string `json:"description,omitempty"`
+	Name        string     `json:"name,omitempty"`
+	Amount      float32    `json:"amount,omitempty"`
+	UnitType    string     `json:"unitType,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	Section     *string    `json:"section,omitempty"`
+	RecipeID    *uuid.UUID `json:"recipeId,omitempty"`
+	Nutrition   *Nutrition `json:"nutrition,omitempty"`
 }
 
 type UpdateStep struct {
-	Title       *string `json:"title,omitempty"`
-	Description string  `json:"description,omitempty"`
+	Title             *string          `json:"title,omitempty"`
+	Description       string           `json:"description,omitempty"`
+	Section           *string          `json:"section,omitempty"`
+	Timers            []StepTimer      `json:"timers,omitempty" validate:"omitempty,max=20,dive"`
+	Temperature       *StepTemperature `json:"temperature,omitempty"`
+	IngredientIndexes []uint           `json:"ingredientIndexes,omitempty"`
 }
 
 type UpdateRecipeInfo RecipeInfo
@@ -102,7 +445,7 @@ type UpdateRecipe struct {
 	ShortDescription *string             `json:"shortDescription,omitempty" validate:"omitempty,max=256"`
 	LongDescription  *string             `json:"longDescription,omitempty"`
 	Ingredients      *[]UpdateIngredient `json:"ingredients,omitempty"`
-	Steps            *[]UpdateStep       `json:"steps,omitempty"`
+	Steps            *[]UpdateStep       `json:"steps,omitempty" validate:"omitempty,dive"`
 	ImageID          *uuid.UUID          `json:"-"`
 	Labels           *[]string           `json:"labels,omitempty" validate:"omitempty,dive,min=1,max=60"`
 }
@@ -0,0 +1,49 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/my-cooking-codex/api/db"
+)
+
+// Version of the account export layout, increased on breaking changes
+const ExportSchemaVersion uint = 1
+
+type ExportManifest struct {
+	SchemaVersion   uint      `json:"schemaVersion"`
+	ExportedAt      time.Time `json:"exportedAt"`
+	Username        string    `json:"username"`
+	Recipes         int       `json:"recipes"`
+	PantryLocations int       `json:"pantryLocations"`
+	PantryItems     int       `json:"pantryItems"`
+	Labels          int       `json:"labels"`
+	Images          int       `json:"images"`
+}
+
+type ExportPantryLocation struct {
+	db.UUIDBase
+	db.TimeBase
+	Name  string           `json:"name"`
+	Items []ReadPantryItem `json:"items"`
+}
+
+type ImportAccount struct {
+	// whether to skip or keep both when something already exists
+	Conflicts string `form:"conflicts" validate:"omitempty,oneof=skip keep"`
+}
+
+type ImportCount struct {
+	Created int `json:"created"`
+	Skipped int `json:"skipped"`
+}
+
+// What an account import created, with the new id of everything created
+// mapped from its id in the archive
+type AccountImportReport struct {
+	Recipes         ImportCount             `json:"recipes"`
+	PantryLocations ImportCount             `json:"pantryLocations"`
+	PantryItems     ImportCount             `json:"pantryItems"`
+	Images          ImportCount             `json:"images"`
+	IDs             map[uuid.UUID]uuid.UUID `json:"ids"`
+}
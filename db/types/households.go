@@ -0,0 +1,47 @@
+package types
+
+import (
+	"github.com/google/uuid"
+	"github.com/my-cooking-codex/api/db"
+)
+
+type ReadHousehold struct {
+	db.UUIDBase
+	db.TimeBase
+	Name string `json:"name"`
+	// role of the requesting user
+	Role db.HouseholdRole `json:"role"`
+}
+
+type ReadHouseholdMember struct {
+	UserID   uuid.UUID        `json:"userId"`
+	Username string           `json:"username"`
+	Role     db.HouseholdRole `json:"role"`
+}
+
+type ReadHouseholdWithMembers struct {
+	ReadHousehold
+	Members []ReadHouseholdMember `json:"members"`
+}
+
+type CreateHousehold struct {
+	Name string `json:"name" validate:"required,min=1,max=60"`
+}
+
+type UpdateHousehold struct {
+	Name string `json:"name" validate:"required,min=1,max=60"`
+}
+
+type CreateHouseholdMember struct {
+	Username string           `json:"username" validate:"required"`
+	Role     db.HouseholdRole `json:"role" validate:"required,oneof=owner editor viewer"`
+}
+
+type UpdateHouseholdMember struct {
+	Role db.HouseholdRole `json:"role" validate:"required,oneof=owner editor viewer"`
+}
+
+// Move a resource into a household, or back to just its owner when nil
+type SetHousehold struct {
+	HouseholdID *uuid.UUID `json:"householdId"`
+}
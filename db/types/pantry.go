@@ -19,7 +19,8 @@ type ReadPantryItem struct {
 }
 
 type CreatePantryLocation struct {
-	Name string `json:"name" validate:"required,min=1,max=60"`
+	Name        string     `json:"name" validate:"required,min=1,max=60"`
+	HouseholdID *uuid.UUID `json:"householdId,omitempty"`
 }
 
 type CreatePantryItem struct {
@@ -37,8 +37,18 @@ func InitDB(conf config.DBConfig) error {
 
 	return DB.AutoMigrate(
 		&User{},
+		&Session{},
+		&RecoveryCode{},
+		&APIToken{},
+		&UserIdentity{},
+		&OIDCLoginRequest{},
+		&InviteCode{},
 		&Label{},
+		&Household{},
+		&HouseholdMember{},
 		&Recipe{},
+		&RecipeShare{},
+		&RecipeRevision{},
 		&PantryLocation{},
 		&PantryItem{},
 	)
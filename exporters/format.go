@@ -0,0 +1,135 @@
+package exporters
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/my-cooking-codex/api/db"
+)
+
+type Format string
+
+const (
+	FormatMarkdown Format = "md"
+	FormatText     Format = "txt"
+	FormatPDF      Format = "pdf"
+)
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	case FormatPDF:
+		return "application/pdf"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// A recipe to export, with the content of its image when it has one
+type Recipe struct {
+	db.ReadRecipe
+	Image []byte
+}
+
+var fileNameRegex = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// Get a file name for an export, made safe from its title
+func FileName(title string, format Format) string {
+	name := strings.Trim(fileNameRegex.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	if name == "" {
+		name = "recipe"
+	}
+	return name + "." + string(format)
+}
+
+// Export recipes as a single document, titled when there is more than one
+func Export(format Format, title string, recipes []Recipe) ([]byte, error) {
+	switch format {
+	case FormatMarkdown:
+		return RecipesMarkdown(title, recipes), nil
+	case FormatText:
+		return RecipesText(title, recipes), nil
+	case FormatPDF:
+		return RecipesPDF(title, recipes)
+	}
+	return nil, fmt.Errorf("unknown export format '%s'", format)
+}
+
+func formatAmount(amount float32) string {
+	return strconv.FormatFloat(math.Round(float64(amount)*100)/100, 'f', -1, 64)
+}
+
+// Format minutes as a duration, such as "1 hr 30 min"
+func formatMinutes(minutes uint) string {
+	hours, minutes := minutes/60, minutes%60
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%d min", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("%d hr", hours)
+	}
+	return fmt.Sprintf("%d hr %d min", hours, minutes)
+}
+
+// Format an ingredient as a line, such as "200 g flour, sifted"
+func formatIngredient(ingredient db.RecipeIngredient) string {
+	var parts []string
+	if ingredient.Amount != 0 {
+		parts = append(parts, formatAmount(ingredient.Amount))
+	}
+	if unitType := strings.TrimSpace(ingredient.UnitType); unitType != "" {
+		parts = append(parts, unitType)
+	}
+	parts = append(parts, strings.TrimSpace(ingredient.Name))
+	line := strings.Join(parts, " ")
+	if ingredient.Description != nil && strings.TrimSpace(*ingredient.Description) != "" {
+		line += ", " + strings.TrimSpace(*ingredient.Description)
+	}
+	return line
+}
+
+// A recipe's details shown under its title, as label and value pairs
+func recipeDetails(recipe db.ReadRecipe) [][2]string {
+	var details [][2]string
+	if recipe.Info.Yields != nil {
+		yields := recipe.Info.Yields.Data()
+		details = append(details, [2]string{"Yields", fmt.Sprintf("%d %s", yields.Value, yields.UnitType)})
+	}
+	if recipe.Info.PrepTime != 0 {
+		details = append(details, [2]string{"Prep time", formatMinutes(recipe.Info.PrepTime)})
+	}
+	if recipe.Info.CookTime != 0 {
+		details = append(details, [2]string{"Cook time", formatMinutes(recipe.Info.CookTime)})
+	}
+	return details
+}
+
+func recipeIngredients(recipe db.ReadRecipe) []db.RecipeIngredient {
+	if recipe.Ingredients == nil {
+		return nil
+	}
+	return *recipe.Ingredients
+}
+
+func recipeSteps(recipe db.ReadRecipe) []db.RecipeStep {
+	if recipe.Steps == nil {
+		return nil
+	}
+	return *recipe.Steps
+}
+
+// Get the section an ingredient or step is in, and whether it differs from the one before it
+func nextSection(value *string, previous string) (string, bool) {
+	section := textOrEmpty(value)
+	return section, section != previous
+}
+
+func textOrEmpty(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return strings.TrimSpace(*value)
+}
@@ -0,0 +1,93 @@
+package exporters
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Indent the lines after the first, so they stay within a list item
+func indentLines(text string, indent string) string {
+	return strings.ReplaceAll(strings.TrimSpace(text), "\n", "\n"+indent)
+}
+
+// Start a section within a list, ending the list before it
+func writeSectionMarkdown(builder *strings.Builder, heading string, section string, first bool) {
+	if !first {
+		builder.WriteString("\n")
+	}
+	if section != "" {
+		fmt.Fprintf(builder, "%s## %s\n\n", heading, section)
+	}
+}
+
+func writeRecipeMarkdown(builder *strings.Builder, recipe Recipe, headingLevel int) {
+	heading := strings.Repeat("#", headingLevel)
+	fmt.Fprintf(builder, "%s %s\n\n", heading, recipe.Title)
+
+	if description := textOrEmpty(recipe.ShortDescription); description != "" {
+		fmt.Fprintf(builder, "%s\n\n", description)
+	}
+	if details := recipeDetails(recipe.ReadRecipe); len(details) != 0 {
+		for _, detail := range details {
+			fmt.Fprintf(builder, "- **%s:** %s\n", detail[0], detail[1])
+		}
+		builder.WriteString("\n")
+	}
+	if description := textOrEmpty(recipe.LongDescription); description != "" {
+		fmt.Fprintf(builder, "%s\n\n", description)
+	}
+
+	if ingredients := recipeIngredients(recipe.ReadRecipe); len(ingredients) != 0 {
+		fmt.Fprintf(builder, "%s# Ingredients\n\n", heading)
+		var section string
+		for i, ingredient := range ingredients {
+			if next, changed := nextSection(ingredient.Section, section); changed {
+				writeSectionMarkdown(builder, heading, next, i == 0)
+				section = next
+			}
+			fmt.Fprintf(builder, "- %s\n", formatIngredient(ingredient))
+		}
+		builder.WriteString("\n")
+	}
+
+	if steps := recipeSteps(recipe.ReadRecipe); len(steps) != 0 {
+		fmt.Fprintf(builder, "%s# Method\n\n", heading)
+		var section string
+		for i, step := range steps {
+			if next, changed := nextSection(step.Section, section); changed {
+				writeSectionMarkdown(builder, heading, next, i == 0)
+				section = next
+			}
+			prefix := fmt.Sprintf("%d. ", i+1)
+			builder.WriteString(prefix)
+			if title := textOrEmpty(step.Title); title != "" {
+				fmt.Fprintf(builder, "**%s** ", title)
+			}
+			fmt.Fprintf(builder, "%s\n", indentLines(step.Description, strings.Repeat(" ", len(prefix))))
+		}
+		builder.WriteString("\n")
+	}
+
+	if source := textOrEmpty(recipe.Info.Source); source != "" {
+		fmt.Fprintf(builder, "Source: %s\n\n", source)
+	}
+}
+
+// Render recipes as Markdown, with a table of contents when there is more than one
+func RecipesMarkdown(title string, recipes []Recipe) []byte {
+	var builder strings.Builder
+	if len(recipes) == 1 {
+		writeRecipeMarkdown(&builder, recipes[0], 1)
+	} else {
+		fmt.Fprintf(&builder, "# %s\n\n", title)
+		for i, recipe := range recipes {
+			fmt.Fprintf(&builder, "%d. %s\n", i+1, recipe.Title)
+		}
+		builder.WriteString("\n")
+		for _, recipe := range recipes {
+			builder.WriteString("---\n\n")
+			writeRecipeMarkdown(&builder, recipe, 2)
+		}
+	}
+	return []byte(strings.TrimRight(builder.String(), "\n") + "\n")
+}
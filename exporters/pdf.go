@@ -0,0 +1,228 @@
+package exporters
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"strconv"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	pdfFont           = "DejaVuSansCondensed"
+	pdfMargin         = 15.0
+	pdfListIndent     = 7.0
+	pdfMaxImageHeight = 90.0
+)
+
+// The standard PDF fonts only cover cp1252, so a font covering most scripts is embedded
+var (
+	//go:embed fonts/DejaVuSansCondensed.ttf
+	pdfFontRegular []byte
+	//go:embed fonts/DejaVuSansCondensed-Bold.ttf
+	pdfFontBold []byte
+	//go:embed fonts/DejaVuSansCondensed-Oblique.ttf
+	pdfFontItalic []byte
+)
+
+type pdfWriter struct {
+	pdf *fpdf.Fpdf
+}
+
+func newPDFWriter(title string) *pdfWriter {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin+5)
+	pdf.SetTitle(title, true)
+	pdf.SetCreator("My Cooking Codex", true)
+	pdf.AddUTF8FontFromBytes(pdfFont, "", pdfFontRegular)
+	pdf.AddUTF8FontFromBytes(pdfFont, "B", pdfFontBold)
+	pdf.AddUTF8FontFromBytes(pdfFont, "I", pdfFontItalic)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin)
+		pdf.SetFont(pdfFont, "", 8)
+		pdf.SetTextColor(128, 128, 128)
+		pdf.CellFormat(0, 5, strconv.Itoa(pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	return &pdfWriter{pdf: pdf}
+}
+
+func (w *pdfWriter) contentWidth() float64 {
+	pageWidth, _ := w.pdf.GetPageSize()
+	left, _, right, _ := w.pdf.GetMargins()
+	return pageWidth - left - right
+}
+
+func (w *pdfWriter) setFont(style string, size float64, grey int) {
+	w.pdf.SetFont(pdfFont, style, size)
+	w.pdf.SetTextColor(grey, grey, grey)
+}
+
+func (w *pdfWriter) text(text string, style string, size float64, grey int) {
+	w.setFont(style, size, grey)
+	w.pdf.MultiCell(0, size*0.45, text, "", "L", false)
+	w.pdf.Ln(2)
+}
+
+func (w *pdfWriter) heading(text string, size float64) {
+	w.pdf.Ln(2)
+	w.text(text, "B", size, 0)
+}
+
+// Write a list item, with the marker to the left of the wrapped text
+func (w *pdfWriter) listItem(marker string, title string, text string) {
+	left, _, _, _ := w.pdf.GetMargins()
+	lineHeight := 11 * 0.45
+	w.setFont("", 11, 0)
+	w.pdf.SetX(left)
+	w.pdf.CellFormat(pdfListIndent, lineHeight, marker, "", 0, "L", false, 0, "")
+	w.pdf.SetLeftMargin(left + pdfListIndent)
+	if title != "" {
+		w.setFont("B", 11, 0)
+		w.pdf.MultiCell(0, lineHeight, title, "", "L", false)
+		w.setFont("", 11, 0)
+	}
+	w.pdf.MultiCell(0, lineHeight, text, "", "L", false)
+	w.pdf.SetLeftMargin(left)
+	w.pdf.Ln(1.5)
+}
+
+// Draw an image across the page, skipping images that can't be read
+func (w *pdfWriter) image(name string, content []byte) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil || config.Width == 0 || config.Height == 0 {
+		return
+	}
+	imageType := map[string]string{"jpeg": "JPG", "png": "PNG", "gif": "GIF"}[format]
+	if imageType == "" {
+		return
+	}
+	options := fpdf.ImageOptions{ImageType: imageType}
+	w.pdf.RegisterImageOptionsReader(name, options, bytes.NewReader(content))
+	if w.pdf.Err() {
+		w.pdf.ClearError()
+		return
+	}
+
+	width := w.contentWidth()
+	height := width * float64(config.Height) / float64(config.Width)
+	if height > pdfMaxImageHeight {
+		height = pdfMaxImageHeight
+		width = height * float64(config.Width) / float64(config.Height)
+	}
+	_, pageHeight := w.pdf.GetPageSize()
+	if _, bottom := w.pdf.GetAutoPageBreak(); w.pdf.GetY()+height > pageHeight-bottom {
+		w.pdf.AddPage()
+	}
+	left, _, _, _ := w.pdf.GetMargins()
+	y := w.pdf.GetY()
+	w.pdf.ImageOptions(name, left, y, width, height, false, options, 0, "")
+	w.pdf.SetY(y + height + 4)
+}
+
+// Write a recipe starting on a new page, returning the page it starts on
+func (w *pdfWriter) recipe(recipe Recipe, link int) int {
+	w.pdf.AddPage()
+	page := w.pdf.PageNo()
+	if link != 0 {
+		w.pdf.SetLink(link, 0, -1)
+	}
+	w.pdf.Bookmark(recipe.Title, 0, -1)
+	w.text(recipe.Title, "B", 20, 0)
+
+	if details := recipeDetails(recipe.ReadRecipe); len(details) != 0 {
+		parts := make([]string, len(details))
+		for i, detail := range details {
+			parts[i] = detail[0] + ": " + detail[1]
+		}
+		w.text(strings.Join(parts, "    "), "", 10, 90)
+	}
+	if description := textOrEmpty(recipe.ShortDescription); description != "" {
+		w.text(description, "I", 11, 40)
+	}
+	if len(recipe.Image) != 0 {
+		w.image(recipe.ID.String(), recipe.Image)
+	}
+	if description := textOrEmpty(recipe.LongDescription); description != "" {
+		w.text(description, "", 11, 0)
+	}
+
+	if ingredients := recipeIngredients(recipe.ReadRecipe); len(ingredients) != 0 {
+		w.heading("Ingredients", 14)
+		var section string
+		for _, ingredient := range ingredients {
+			if next, changed := nextSection(ingredient.Section, section); changed {
+				if next != "" {
+					w.heading(next, 12)
+				}
+				section = next
+			}
+			w.listItem("•", "", formatIngredient(ingredient))
+		}
+	}
+	if steps := recipeSteps(recipe.ReadRecipe); len(steps) != 0 {
+		w.heading("Method", 14)
+		var section string
+		for i, step := range steps {
+			if next, changed := nextSection(step.Section, section); changed {
+				if next != "" {
+					w.heading(next, 12)
+				}
+				section = next
+			}
+			w.listItem(fmt.Sprintf("%d.", i+1), textOrEmpty(step.Title), strings.TrimSpace(step.Description))
+		}
+	}
+
+	if source := textOrEmpty(recipe.Info.Source); source != "" {
+		w.pdf.Ln(2)
+		w.text("Source: "+source, "", 9, 110)
+	}
+	return page
+}
+
+// Write a table of contents, with page numbers filled in once the recipes are written
+func (w *pdfWriter) contents(title string, recipes []Recipe) []int {
+	w.pdf.AddPage()
+	w.text(title, "B", 24, 0)
+	w.heading("Contents", 14)
+	numberWidth := 15.0
+	links := make([]int, len(recipes))
+	for i, recipe := range recipes {
+		links[i] = w.pdf.AddLink()
+		w.setFont("", 12, 0)
+		w.pdf.CellFormat(w.contentWidth()-numberWidth, 7, recipe.Title, "", 0, "L", false, links[i], "")
+		w.pdf.CellFormat(numberWidth, 7, fmt.Sprintf("{page%d}", i), "", 1, "L", false, links[i], "")
+	}
+	return links
+}
+
+// Render recipes as a PDF, as a cookbook with a table of contents
+// when there is more than one
+func RecipesPDF(title string, recipes []Recipe) ([]byte, error) {
+	if len(recipes) == 1 {
+		title = recipes[0].Title
+	}
+	w := newPDFWriter(title)
+
+	links := make([]int, len(recipes))
+	if len(recipes) > 1 {
+		links = w.contents(title, recipes)
+	}
+	for i, recipe := range recipes {
+		page := w.recipe(recipe, links[i])
+		w.pdf.RegisterAlias(fmt.Sprintf("{page%d}", i), strconv.Itoa(page))
+	}
+
+	var output bytes.Buffer
+	if err := w.pdf.Output(&output); err != nil {
+		return nil, err
+	}
+	return output.Bytes(), nil
+}
@@ -0,0 +1,49 @@
+package exporters
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/my-cooking-codex/api/db"
+)
+
+func TestRecipesPDFUnicode(t *testing.T) {
+	recipes := []Recipe{}
+	for _, title := range []string{"Crème brûlée", "Борщ", "Σουβλάκι", "Đậu phụ sốt cà chua"} {
+		description := title + " – 2½ cups, 180 °C"
+		recipes = append(recipes, Recipe{ReadRecipe: db.ReadRecipe{
+			UUIDBase:         db.UUIDBase{ID: uuid.New()},
+			Title:            title,
+			ShortDescription: &description,
+		}})
+	}
+
+	// the same steps as RecipesPDF, uncompressed so the output can be checked
+	w := newPDFWriter("Cookbook")
+	w.pdf.SetCompression(false)
+	links := w.contents("Cookbook", recipes)
+	for i, recipe := range recipes {
+		page := w.recipe(recipe, links[i])
+		w.pdf.RegisterAlias(fmt.Sprintf("{page%d}", i), strconv.Itoa(page))
+	}
+	var output bytes.Buffer
+	if err := w.pdf.Output(&output); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(output.Bytes(), []byte("/FontFile2")) {
+		t.Error("expected the font to be embedded")
+	}
+	// text in embedded fonts is written as UTF-16
+	for _, alias := range []string{"{page", "\x00{\x00p\x00a\x00g\x00e"} {
+		if bytes.Contains(output.Bytes(), []byte(alias)) {
+			t.Error("expected page numbers to replace their aliases")
+		}
+	}
+
+	if _, err := RecipesPDF("Cookbook", recipes); err != nil {
+		t.Fatal(err)
+	}
+}
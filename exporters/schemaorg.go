@@ -0,0 +1,104 @@
+package exporters
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/my-cooking-codex/api/core"
+	"github.com/my-cooking-codex/api/db"
+)
+
+const SchemaContentType = "application/ld+json"
+
+// Nutrition in one serving
+type SchemaNutrition struct {
+	Type                string `json:"@type"`
+	Calories            string `json:"calories"`
+	ProteinContent      string `json:"proteinContent"`
+	FatContent          string `json:"fatContent"`
+	CarbohydrateContent string `json:"carbohydrateContent"`
+	FiberContent        string `json:"fiberContent"`
+	SodiumContent       string `json:"sodiumContent"`
+}
+
+type SchemaStep struct {
+	Type string `json:"@type"`
+	Name string `json:"name,omitempty"`
+	Text string `json:"text"`
+}
+
+// A recipe as a schema.org Recipe, for other tools to read
+type SchemaRecipe struct {
+	Context            string           `json:"@context"`
+	Type               string           `json:"@type"`
+	Name               string           `json:"name"`
+	Description        string           `json:"description,omitempty"`
+	Image              string           `json:"image,omitempty"`
+	RecipeYield        string           `json:"recipeYield,omitempty"`
+	PrepTime           string           `json:"prepTime,omitempty"`
+	CookTime           string           `json:"cookTime,omitempty"`
+	TotalTime          string           `json:"totalTime,omitempty"`
+	RecipeIngredient   []string         `json:"recipeIngredient,omitempty"`
+	RecipeInstructions []SchemaStep     `json:"recipeInstructions,omitempty"`
+	Keywords           string           `json:"keywords,omitempty"`
+	IsBasedOn          string           `json:"isBasedOn,omitempty"`
+	Nutrition          *SchemaNutrition `json:"nutrition,omitempty"`
+	DateCreated        time.Time        `json:"dateCreated"`
+	DateModified       time.Time        `json:"dateModified"`
+}
+
+func schemaDuration(minutes uint) string {
+	if minutes == 0 {
+		return ""
+	}
+	return core.FormatISO8601Duration(time.Duration(minutes) * time.Minute)
+}
+
+// Map a recipe to schema.org, with the absolute url of its image if it has one
+func RecipeSchema(recipe db.ReadRecipe, imageURL string) SchemaRecipe {
+	schema := SchemaRecipe{
+		Context:      "https://schema.org",
+		Type:         "Recipe",
+		Name:         recipe.Title,
+		Description:  textOrEmpty(recipe.ShortDescription),
+		Image:        imageURL,
+		PrepTime:     schemaDuration(recipe.Info.PrepTime),
+		CookTime:     schemaDuration(recipe.Info.CookTime),
+		TotalTime:    schemaDuration(recipe.Info.PrepTime + recipe.Info.CookTime),
+		Keywords:     strings.Join(recipe.Labels, ", "),
+		IsBasedOn:    textOrEmpty(recipe.Info.Source),
+		DateCreated:  recipe.CreatedAt,
+		DateModified: recipe.UpdatedAt,
+	}
+	if schema.Description == "" {
+		schema.Description = textOrEmpty(recipe.LongDescription)
+	}
+	if recipe.Info.Yields != nil {
+		yields := recipe.Info.Yields.Data()
+		schema.RecipeYield = strings.TrimSpace(fmt.Sprintf("%d %s", yields.Value, yields.UnitType))
+	}
+	for _, ingredient := range recipeIngredients(recipe) {
+		schema.RecipeIngredient = append(schema.RecipeIngredient, formatIngredient(ingredient))
+	}
+	for _, step := range recipeSteps(recipe) {
+		schema.RecipeInstructions = append(schema.RecipeInstructions, SchemaStep{
+			Type: "HowToStep",
+			Name: textOrEmpty(step.Title),
+			Text: strings.TrimSpace(step.Description),
+		})
+	}
+	if recipe.Nutrition != nil && recipe.Nutrition.PerServing != nil {
+		perServing := recipe.Nutrition.PerServing
+		schema.Nutrition = &SchemaNutrition{
+			Type:                "NutritionInformation",
+			Calories:            fmt.Sprintf("%g calories", perServing.Calories),
+			ProteinContent:      fmt.Sprintf("%g g", perServing.Protein),
+			FatContent:          fmt.Sprintf("%g g", perServing.Fat),
+			CarbohydrateContent: fmt.Sprintf("%g g", perServing.Carbohydrates),
+			FiberContent:        fmt.Sprintf("%g g", perServing.Fiber),
+			SodiumContent:       fmt.Sprintf("%g mg", perServing.Sodium),
+		}
+	}
+	return schema
+}
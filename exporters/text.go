@@ -0,0 +1,89 @@
+package exporters
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+func underline(text string, char string) string {
+	return text + "\n" + strings.Repeat(char, utf8.RuneCountInString(text))
+}
+
+func writeRecipeText(builder *strings.Builder, recipe Recipe) {
+	fmt.Fprintf(builder, "%s\n\n", underline(recipe.Title, "="))
+
+	if description := textOrEmpty(recipe.ShortDescription); description != "" {
+		fmt.Fprintf(builder, "%s\n\n", description)
+	}
+	if details := recipeDetails(recipe.ReadRecipe); len(details) != 0 {
+		for _, detail := range details {
+			fmt.Fprintf(builder, "%s: %s\n", detail[0], detail[1])
+		}
+		builder.WriteString("\n")
+	}
+	if description := textOrEmpty(recipe.LongDescription); description != "" {
+		fmt.Fprintf(builder, "%s\n\n", description)
+	}
+
+	if ingredients := recipeIngredients(recipe.ReadRecipe); len(ingredients) != 0 {
+		fmt.Fprintf(builder, "%s\n\n", underline("Ingredients", "-"))
+		var section string
+		for i, ingredient := range ingredients {
+			if next, changed := nextSection(ingredient.Section, section); changed {
+				if i != 0 {
+					builder.WriteString("\n")
+				}
+				if next != "" {
+					fmt.Fprintf(builder, "%s:\n", next)
+				}
+				section = next
+			}
+			fmt.Fprintf(builder, "* %s\n", formatIngredient(ingredient))
+		}
+		builder.WriteString("\n")
+	}
+
+	if steps := recipeSteps(recipe.ReadRecipe); len(steps) != 0 {
+		fmt.Fprintf(builder, "%s\n\n", underline("Method", "-"))
+		var section string
+		for i, step := range steps {
+			if next, changed := nextSection(step.Section, section); changed {
+				if next != "" {
+					fmt.Fprintf(builder, "%s:\n\n", next)
+				}
+				section = next
+			}
+			prefix := fmt.Sprintf("%d. ", i+1)
+			indent := strings.Repeat(" ", len(prefix))
+			builder.WriteString(prefix)
+			if title := textOrEmpty(step.Title); title != "" {
+				fmt.Fprintf(builder, "%s\n%s", title, indent)
+			}
+			fmt.Fprintf(builder, "%s\n\n", indentLines(step.Description, indent))
+		}
+	}
+
+	if source := textOrEmpty(recipe.Info.Source); source != "" {
+		fmt.Fprintf(builder, "Source: %s\n\n", source)
+	}
+}
+
+// Render recipes as plain text, with a list of contents when there is more than one
+func RecipesText(title string, recipes []Recipe) []byte {
+	var builder strings.Builder
+	if len(recipes) != 1 {
+		fmt.Fprintf(&builder, "%s\n\n", underline(title, "#"))
+		for i, recipe := range recipes {
+			fmt.Fprintf(&builder, "%d. %s\n", i+1, recipe.Title)
+		}
+		builder.WriteString("\n\n")
+	}
+	for i, recipe := range recipes {
+		if i != 0 {
+			builder.WriteString("\n")
+		}
+		writeRecipeText(&builder, recipe)
+	}
+	return []byte(strings.TrimRight(builder.String(), "\n") + "\n")
+}
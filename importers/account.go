@@ -0,0 +1,78 @@
+package importers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/google/uuid"
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/db/types"
+)
+
+var ErrUnsupportedSchema = errors.New("export is from an unsupported version")
+
+// An account export, as written by the export endpoint
+type AccountArchive struct {
+	Manifest        types.ExportManifest
+	Recipes         []db.ReadRecipe
+	PantryLocations []types.ExportPantryLocation
+	Labels          []string
+	// image content, by the id it had when exported
+	Images map[uuid.UUID][]byte
+}
+
+func unmarshalArchiveFile(file archiveFile, v any) error {
+	if err := json.Unmarshal(file.Content, v); err != nil {
+		return fmt.Errorf("%w: %s: %s", ErrInvalidArchive, file.Name, err)
+	}
+	return nil
+}
+
+// Read an account export, extracting no more than maxSize bytes from it
+func ReadAccountArchive(content []byte, maxSize int64) (AccountArchive, error) {
+	files, err := readZip(content, &extractLimit{remaining: maxSize})
+	if err != nil {
+		return AccountArchive{}, err
+	}
+
+	archive := AccountArchive{Images: map[uuid.UUID][]byte{}}
+	hasManifest := false
+	for _, file := range files {
+		switch dir := path.Dir(file.Name); {
+		case file.Name == "manifest.json":
+			if err := unmarshalArchiveFile(file, &archive.Manifest); err != nil {
+				return AccountArchive{}, err
+			}
+			hasManifest = true
+		case file.Name == "labels.json":
+			if err := unmarshalArchiveFile(file, &archive.Labels); err != nil {
+				return AccountArchive{}, err
+			}
+		case dir == "recipes" && hasExtension(file.Name, ".json"):
+			var recipe db.ReadRecipe
+			if err := unmarshalArchiveFile(file, &recipe); err != nil {
+				return AccountArchive{}, err
+			}
+			archive.Recipes = append(archive.Recipes, recipe)
+		case dir == "pantry" && hasExtension(file.Name, ".json"):
+			var location types.ExportPantryLocation
+			if err := unmarshalArchiveFile(file, &location); err != nil {
+				return AccountArchive{}, err
+			}
+			archive.PantryLocations = append(archive.PantryLocations, location)
+		case dir == "images":
+			if imageID, err := uuid.Parse(baseName(file.Name)); err == nil {
+				archive.Images[imageID] = file.Content
+			}
+		}
+	}
+
+	if !hasManifest {
+		return AccountArchive{}, fmt.Errorf("%w: missing manifest.json", ErrInvalidArchive)
+	} else if archive.Manifest.SchemaVersion == 0 || archive.Manifest.SchemaVersion > types.ExportSchemaVersion {
+		return AccountArchive{}, ErrUnsupportedSchema
+	}
+	return archive, nil
+}
@@ -0,0 +1,178 @@
+package importers
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+var (
+	ErrInvalidArchive  = errors.New("file is not a valid export")
+	ErrArchiveTooLarge = errors.New("archive content is too large")
+)
+
+// Most files that will be read from a single archive
+const maxArchiveFiles = 10000
+
+type ArchiveFormat string
+
+const (
+	FormatPaprika  ArchiveFormat = "paprika"
+	FormatMealie   ArchiveFormat = "mealie"
+	FormatTandoor  ArchiveFormat = "tandoor"
+	FormatCooklang ArchiveFormat = "cooklang"
+)
+
+// A recipe read from an archive, or why it couldn't be read
+type ArchiveEntry struct {
+	Name   string
+	Recipe ImportedRecipe
+	Err    error
+}
+
+type archiveFile struct {
+	Name    string
+	Content []byte
+}
+
+// Tracks how much has been extracted from an archive,
+// so a small archive can't expand into something huge
+type extractLimit struct {
+	remaining int64
+	files     int
+}
+
+func (l *extractLimit) read(r io.Reader) ([]byte, error) {
+	content, err := io.ReadAll(io.LimitReader(r, l.remaining+1))
+	if err != nil {
+		return nil, invalidArchive(err)
+	} else if int64(len(content)) > l.remaining {
+		return nil, ErrArchiveTooLarge
+	}
+	l.remaining -= int64(len(content))
+	return content, nil
+}
+
+// Mark an error as coming from a file that couldn't be read
+func invalidArchive(err error) error {
+	if errors.Is(err, ErrInvalidArchive) || errors.Is(err, ErrArchiveTooLarge) {
+		return err
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidArchive, err)
+}
+
+func isZip(content []byte) bool {
+	return bytes.HasPrefix(content, []byte("PK\x03\x04"))
+}
+
+// Files such as those added by macOS that aren't part of an export
+func isHiddenPath(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
+
+func hasExtension(name string, extensions ...string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	for _, extension := range extensions {
+		if ext == extension {
+			return true
+		}
+	}
+	return false
+}
+
+// Name of a file without its directory or extension
+func baseName(name string) string {
+	name = path.Base(name)
+	return strings.TrimSuffix(name, path.Ext(name))
+}
+
+// Read every file in a zip archive, skipping directories and hidden files
+func readZip(content []byte, limit *extractLimit) ([]archiveFile, error) {
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, invalidArchive(err)
+	}
+	var files []archiveFile
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() || isHiddenPath(file.Name) {
+			continue
+		}
+		if limit.files++; limit.files > maxArchiveFiles {
+			return nil, ErrArchiveTooLarge
+		}
+		opened, err := file.Open()
+		if err != nil {
+			return nil, invalidArchive(err)
+		}
+		fileContent, err := limit.read(opened)
+		opened.Close()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, archiveFile{Name: file.Name, Content: fileContent})
+	}
+	return files, nil
+}
+
+func gunzip(content []byte, limit *extractLimit) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, invalidArchive(err)
+	}
+	defer reader.Close()
+	return limit.read(reader)
+}
+
+// Find an image for a recipe, named after it and in the same directory
+func findImage(files []archiveFile, dir string, names ...string) []byte {
+	for _, name := range names {
+		for _, file := range files {
+			if path.Dir(file.Name) == dir &&
+				baseName(file.Name) == name &&
+				hasExtension(file.Name, ".jpg", ".jpeg", ".png", ".webp") {
+				return file.Content
+			}
+		}
+	}
+	return nil
+}
+
+// Read the recipes in an export from another recipe manager,
+// extracting no more than maxSize bytes from it
+func ReadArchive(format ArchiveFormat, filename string, content []byte, maxSize int64) ([]ArchiveEntry, error) {
+	limit := &extractLimit{remaining: maxSize}
+	var entries []ArchiveEntry
+	var err error
+	switch format {
+	case FormatPaprika:
+		entries, err = readPaprika(filename, content, limit)
+	case FormatMealie:
+		entries, err = readMealie(filename, content, limit)
+	case FormatTandoor:
+		entries, err = readTandoor(content, limit)
+	case FormatCooklang:
+		entries, err = readCooklang(filename, content, limit)
+	default:
+		return nil, fmt.Errorf("unknown archive format '%s'", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// running out part way through means the rest would fail too
+	for _, entry := range entries {
+		if errors.Is(entry.Err, ErrArchiveTooLarge) {
+			return nil, ErrArchiveTooLarge
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,188 @@
+package importers
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+// An expected result of reading an archive, from testdata/archives.json
+type archiveCase struct {
+	Format  ArchiveFormat `json:"format"`
+	File    string        `json:"file"`
+	Entries []struct {
+		Name string `json:"name"`
+		// "invalid" or "no recipe" for those errors, any other value for any error
+		Err    string          `json:"error"`
+		Image  bool            `json:"image"`
+		Recipe json.RawMessage `json:"recipe"`
+	} `json:"entries"`
+}
+
+func TestReadArchive(t *testing.T) {
+	content, err := os.ReadFile("testdata/archives.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cases []archiveCase
+	if err := json.Unmarshal(content, &cases); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range cases {
+		t.Run(test.File, func(t *testing.T) {
+			content, err := os.ReadFile("testdata/" + test.File)
+			if err != nil {
+				t.Fatal(err)
+			}
+			entries, err := ReadArchive(test.Format, test.File, content, 1<<20)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != len(test.Entries) {
+				t.Fatalf("entries = %d, want %d", len(entries), len(test.Entries))
+			}
+			for i, want := range test.Entries {
+				got := entries[i]
+				if got.Name != want.Name {
+					t.Errorf("entry %d name = %q, want %q", i, got.Name, want.Name)
+				}
+				switch want.Err {
+				case "":
+					if got.Err != nil {
+						t.Errorf("%s: error = %v, want none", want.Name, got.Err)
+					}
+				case "invalid":
+					if !errors.Is(got.Err, ErrInvalidArchive) {
+						t.Errorf("%s: error = %v, want ErrInvalidArchive", want.Name, got.Err)
+					}
+				case "no recipe":
+					if !errors.Is(got.Err, ErrNoRecipe) {
+						t.Errorf("%s: error = %v, want ErrNoRecipe", want.Name, got.Err)
+					}
+				default:
+					if got.Err == nil {
+						t.Errorf("%s: expected an error", want.Name)
+					}
+				}
+				if hasImage := len(got.Recipe.Image) != 0; hasImage != want.Image {
+					t.Errorf("%s: has image = %v, want %v", want.Name, hasImage, want.Image)
+				}
+				if want.Recipe == nil {
+					continue
+				}
+				// compared as indented JSON, so differences are easy to spot
+				var gotJSON, wantJSON bytes.Buffer
+				raw, _ := json.Marshal(got.Recipe.Recipe)
+				json.Indent(&gotJSON, raw, "", "  ")
+				json.Indent(&wantJSON, want.Recipe, "", "  ")
+				if gotJSON.String() != wantJSON.String() {
+					t.Errorf("%s: recipe = %s\nwant %s", want.Name, gotJSON.String(), wantJSON.String())
+				}
+			}
+		})
+	}
+}
+
+func makeZip(t *testing.T, names []string, content func(name string) []byte) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for _, name := range names {
+		file, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := file.Write(content(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func TestReadArchiveLimits(t *testing.T) {
+	// compresses to almost nothing, but is far larger than the limit once extracted
+	zeros := func(string) []byte { return make([]byte, 1<<20) }
+	recipe := func(string) []byte { return []byte("Toast @bread{1%slice}.") }
+	manyNames := make([]string, maxArchiveFiles+1)
+	for i := range manyNames {
+		manyNames[i] = fmt.Sprintf("%d.txt", i)
+	}
+	var gzipped bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipped)
+	gzipWriter.Write(zeros(""))
+	gzipWriter.Close()
+
+	tests := []struct {
+		name    string
+		format  ArchiveFormat
+		content []byte
+		maxSize int64
+		want    error
+	}{
+		{"zip bomb", FormatCooklang, makeZip(t, []string{"bomb.cook"}, zeros), 1000, ErrArchiveTooLarge},
+		{"within the limit", FormatCooklang, makeZip(t, []string{"toast.cook"}, recipe), 1000, nil},
+		// files that aren't recipes still count
+		{"too many files", FormatCooklang, makeZip(t, manyNames, recipe), 1 << 30, ErrArchiveTooLarge},
+		{"hidden files are not counted", FormatCooklang, makeZip(t, append(manyNames[1:], "__MACOSX/._toast.cook"), recipe), 1 << 30, nil},
+		{"gzipped bomb", FormatPaprika, gzipped.Bytes(), 1000, ErrArchiveTooLarge},
+		{
+			"nested bomb",
+			FormatTandoor,
+			makeZip(t, []string{"1.zip", "2.zip"}, func(name string) []byte {
+				return makeZip(t, []string{"recipe.json", "image.jpg"}, func(name string) []byte {
+					if name == "recipe.json" {
+						return []byte(`{"name": "Toast"}`)
+					}
+					return zeros(name)
+				})
+			}),
+			1 << 19,
+			ErrArchiveTooLarge,
+		},
+		{"not a zip", FormatTandoor, []byte("not a zip"), 1000, ErrInvalidArchive},
+		{"not a paprika recipe", FormatPaprika, []byte("not gzip"), 1000, ErrInvalidArchive},
+		{"not a mealie recipe", FormatMealie, []byte("{"), 1000, ErrInvalidArchive},
+		{"not cooklang", FormatCooklang, []byte{0xff, 0xfe}, 1000, ErrInvalidArchive},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ReadArchive(test.format, "export", test.content, test.maxSize); !errors.Is(err, test.want) {
+				t.Errorf("error = %v, want %v", err, test.want)
+			}
+		})
+	}
+
+	if _, err := ReadArchive("unknown", "export", nil, 1000); err == nil {
+		t.Error("expected an unknown format to be rejected")
+	}
+}
+
+func TestParseCooklangAmount(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float32
+		ok    bool
+	}{
+		{"2", 2, true},
+		{"1.5", 1.5, true},
+		{"1/2", 0.5, true},
+		{"1 / 2", 0.5, true},
+		{"1 1/2", 1.5, true},
+		{"1/0", 0, false},
+		{"some", 0, false},
+		{"", 0, false},
+	}
+	for _, test := range tests {
+		if got, ok := parseCooklangAmount(test.value); got != test.want || ok != test.ok {
+			t.Errorf("parseCooklangAmount(%q) = %v, %v, want %v, %v", test.value, got, ok, test.want, test.ok)
+		}
+	}
+}
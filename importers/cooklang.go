@@ -0,0 +1,206 @@
+package importers
+
+import (
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/my-cooking-codex/api/db"
+)
+
+var (
+	cooklangBlockCommentRegex = regexp.MustCompile(`(?s)\[-.*?-\]`)
+	cooklangLineCommentRegex  = regexp.MustCompile(`--.*`)
+	cooklangMetadataRegex     = regexp.MustCompile(`^>>\s*([^:]+?)\s*:\s*(.*)$`)
+	cooklangSectionRegex      = regexp.MustCompile(`^=+\s*(.*?)\s*=*$`)
+	// @name{quantity%unit}, or @name for a single word
+	cooklangIngredientRegex = regexp.MustCompile(`@[@?+&-]*(?:([^@#~{}\n]+?)\{([^}]*)\}|([\p{L}\p{N}_]+))`)
+	cooklangCookwareRegex   = regexp.MustCompile(`#[?+&-]*(?:([^@#~{}\n]+?)\{[^}]*\}|([\p{L}\p{N}_]+))`)
+	cooklangTimerRegex      = regexp.MustCompile(`~([^@#~{}\n]*)\{([^}]*)\}`)
+)
+
+// Parse a Cooklang quantity, such as "2", "1.5", "1/2" or "1 1/2"
+func parseCooklangAmount(value string) (float32, bool) {
+	if fields := strings.Fields(value); len(fields) == 2 && !strings.Contains(fields[0], "/") && strings.Contains(fields[1], "/") {
+		whole, ok := parseCooklangAmount(fields[0])
+		fraction, fractionOK := parseCooklangAmount(fields[1])
+		return whole + fraction, ok && fractionOK
+	}
+	value = strings.ReplaceAll(value, " ", "")
+	if numerator, denominator, ok := strings.Cut(value, "/"); ok {
+		n, err := strconv.ParseFloat(numerator, 32)
+		d, dErr := strconv.ParseFloat(denominator, 32)
+		if err != nil || dErr != nil || d == 0 {
+			return 0, false
+		}
+		return float32(n / d), true
+	}
+	amount, err := strconv.ParseFloat(value, 32)
+	return float32(amount), err == nil
+}
+
+// Split Cooklang's quantity%unit into its parts
+func splitCooklangQuantity(quantity string) (string, string) {
+	amount, unit, _ := strings.Cut(quantity, "%")
+	return strings.TrimSpace(amount), strings.TrimSpace(unit)
+}
+
+// Read a recipe written in Cooklang, titled from its metadata or file name
+func parseCooklang(name string, source string) ImportedRecipe {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	source = cooklangBlockCommentRegex.ReplaceAllString(source, "")
+
+	metadata := map[string]string{}
+	// YAML front matter, only simple key: value pairs are understood
+	if strings.HasPrefix(source, "---\n") {
+		if end := strings.Index(source[4:], "\n---"); end != -1 {
+			for _, line := range strings.Split(source[4:4+end], "\n") {
+				if key, value, ok := strings.Cut(line, ":"); ok {
+					metadata[strings.ToLower(strings.TrimSpace(key))] = strings.Trim(strings.TrimSpace(value), `"'`)
+				}
+			}
+			source = source[4+end+4:]
+		}
+	}
+
+	var recipe db.CreateRecipe
+	var paragraph []string
+	var sectionTitle string
+	ingredientIndexes := map[string]int{}
+
+	addIngredient := func(name string, quantity string) {
+		name = strings.TrimSpace(name)
+		amountText, unit := splitCooklangQuantity(quantity)
+		amount, isNumber := parseCooklangAmount(amountText)
+		// the same ingredient used again is added to the first use
+		key := strings.ToLower(name) + "%" + strings.ToLower(unit)
+		if i, ok := ingredientIndexes[key]; ok && isNumber {
+			recipe.Ingredients[i].Amount += amount
+			return
+		}
+		ingredient := db.RecipeIngredient{Name: name, UnitType: unit}
+		if sectionTitle != "" {
+			section := truncate(sectionTitle, 60)
+			ingredient.Section = &section
+		}
+		if isNumber {
+			ingredient.Amount = amount
+			ingredientIndexes[key] = len(recipe.Ingredients)
+		} else if amountText != "" {
+			ingredient.Description = &amountText
+		}
+		recipe.Ingredients = append(recipe.Ingredients, ingredient)
+	}
+
+	endStep := func() {
+		if len(paragraph) == 0 {
+			return
+		}
+		text := strings.Join(paragraph, " ")
+		paragraph = nil
+		text = cooklangIngredientRegex.ReplaceAllStringFunc(text, func(match string) string {
+			parts := cooklangIngredientRegex.FindStringSubmatch(match)
+			name := parts[1] + parts[3]
+			addIngredient(name, parts[2])
+			return strings.TrimSpace(name)
+		})
+		text = cooklangCookwareRegex.ReplaceAllString(text, "$1$2")
+		text = cooklangTimerRegex.ReplaceAllStringFunc(text, func(match string) string {
+			parts := cooklangTimerRegex.FindStringSubmatch(match)
+			amount, unit := splitCooklangQuantity(parts[2])
+			return strings.TrimSpace(amount + " " + unit)
+		})
+		text = strings.TrimSpace(whitespaceRegex.ReplaceAllString(text, " "))
+		if text == "" {
+			return
+		}
+		step := db.RecipeStep{Description: text}
+		if sectionTitle != "" {
+			section := truncate(sectionTitle, 60)
+			step.Section = &section
+		}
+		recipe.Steps = append(recipe.Steps, step)
+	}
+
+	for _, line := range strings.Split(source, "\n") {
+		line = strings.TrimSpace(cooklangLineCommentRegex.ReplaceAllString(line, ""))
+		if match := cooklangMetadataRegex.FindStringSubmatch(line); match != nil {
+			metadata[strings.ToLower(match[1])] = strings.TrimSpace(match[2])
+			continue
+		}
+		if line == "" {
+			endStep()
+			continue
+		}
+		if match := cooklangSectionRegex.FindStringSubmatch(line); match != nil {
+			endStep()
+			sectionTitle = match[1]
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+	endStep()
+
+	recipe.Title = recipeTitle(metadata["title"])
+	if metadata["title"] == "" {
+		recipe.Title = recipeTitle(baseName(name))
+	}
+	setDescription(&recipe, metadata["description"])
+	recipe.Labels = schemaLabels(metadata["tags"])
+	for _, key := range []string{"servings", "serves", "yield"} {
+		if yields := schemaYields(metadata[key]); yields != nil {
+			recipe.Info.Yields = yields
+			break
+		}
+	}
+	recipe.Info.PrepTime = textMinutes(metadata["prep time"])
+	recipe.Info.CookTime = textMinutes(metadata["cook time"])
+	for _, key := range []string{"time required", "time"} {
+		if totalTime := textMinutes(metadata[key]); recipe.Info.CookTime == 0 && totalTime > recipe.Info.PrepTime {
+			recipe.Info.CookTime = totalTime - recipe.Info.PrepTime
+		}
+	}
+	for _, key := range []string{"source", "source.url"} {
+		if source := metadata[key]; source != "" {
+			recipe.Info.Source = &source
+			break
+		}
+	}
+
+	return ImportedRecipe{Recipe: recipe}
+}
+
+// Read Cooklang recipes, either a single .cook file or a zip of them
+// with images named after the recipe next to each
+func readCooklang(filename string, content []byte, limit *extractLimit) ([]ArchiveEntry, error) {
+	if !isZip(content) {
+		if !utf8.Valid(content) {
+			return nil, invalidArchive(ErrNoRecipe)
+		}
+		recipe := parseCooklang(filename, string(content))
+		return []ArchiveEntry{{Name: recipe.Recipe.Title, Recipe: recipe}}, nil
+	}
+
+	files, err := readZip(content, limit)
+	if err != nil {
+		return nil, err
+	}
+	var entries []ArchiveEntry
+	for _, file := range files {
+		if !hasExtension(file.Name, ".cook") {
+			continue
+		}
+		entry := ArchiveEntry{Name: baseName(file.Name)}
+		if !utf8.Valid(file.Content) {
+			entry.Err = ErrNoRecipe
+		} else {
+			entry.Recipe = parseCooklang(file.Name, string(file.Content))
+			entry.Recipe.Image = findImage(files, path.Dir(file.Name), baseName(file.Name))
+			entry.Name = entry.Recipe.Recipe.Title
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
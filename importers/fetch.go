@@ -0,0 +1,119 @@
+package importers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrHostNotAllowed = errors.New("host is not allowed")
+	ErrTooLarge       = errors.New("response is too large")
+)
+
+const maxRedirects = 5
+
+// Carrier-grade NAT range, which net.IP.IsPrivate doesn't cover
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// Whether an address is reachable on the public internet
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		sharedAddressSpace.Contains(ip))
+}
+
+// Fetches pages and images from other sites,
+// refusing to connect to private addresses unless allowed
+type Fetcher struct {
+	client *http.Client
+}
+
+func NewFetcher(timeout time.Duration, allowPrivateHosts bool) *Fetcher {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivateHosts {
+		// checked on connect, so hosts resolving to private addresses are caught too
+		dialer.Control = func(network string, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return ErrHostNotAllowed
+			}
+			return nil
+		}
+	}
+	return &Fetcher{
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				// a proxy would bypass the address check
+				Proxy:                 nil,
+				DialContext:           dialer.DialContext,
+				TLSHandshakeTimeout:   timeout,
+				ResponseHeaderTimeout: timeout,
+				MaxIdleConns:          10,
+				IdleConnTimeout:       90 * time.Second,
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= maxRedirects {
+					return errors.New("too many redirects")
+				}
+				return checkURL(req.URL)
+			},
+		},
+	}
+}
+
+func checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ErrHostNotAllowed
+	}
+	return nil
+}
+
+// Get the content at a url, no larger than maxSize bytes.
+// Returns the content and the final url after any redirects
+func (f *Fetcher) Get(ctx context.Context, rawURL string, maxSize int64) ([]byte, *url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkURL(u); err != nil {
+		return nil, nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("User-Agent", "MyCookingCodex/1.0 (recipe import)")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, u)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, nil, err
+	} else if int64(len(content)) > maxSize {
+		return nil, nil, ErrTooLarge
+	}
+	return content, resp.Request.URL, nil
+}
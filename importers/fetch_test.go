@@ -0,0 +1,122 @@
+package importers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"8.8.8.8", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"127.1.2.3", false},
+		{"::1", false},
+		{"10.0.0.1", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+	}
+	for _, test := range tests {
+		if got := isPublicIP(net.ParseIP(test.ip)); got != test.want {
+			t.Errorf("isPublicIP(%s) = %v, want %v", test.ip, got, test.want)
+		}
+	}
+}
+
+func TestFetcherRejectsPrivateHosts(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte("secret"))
+	}))
+	defer server.Close()
+
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	fetcher := NewFetcher(time.Second, false)
+	for _, rawURL := range []string{
+		server.URL,
+		// the address is only known once the name is resolved
+		"http://localhost:" + port,
+		"http://[::1]:1/",
+		"http://10.0.0.1:1/",
+	} {
+		if _, _, err := fetcher.Get(context.Background(), rawURL, 1024); !errors.Is(err, ErrHostNotAllowed) {
+			t.Errorf("Get(%s) error = %v, want ErrHostNotAllowed", rawURL, err)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("server got %d requests, want none", requests)
+	}
+}
+
+func TestFetcherGet(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("page"))
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/page", http.StatusFound)
+	})
+	mux.HandleFunc("/file", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "file:///etc/passwd", http.StatusFound)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fetcher := NewFetcher(time.Second, true)
+	content, finalURL, err := fetcher.Get(context.Background(), server.URL+"/moved", 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "page" {
+		t.Errorf("content = %q, want page", content)
+	}
+	if finalURL.Path != "/page" {
+		t.Errorf("final url = %s, want the redirect's target", finalURL)
+	}
+
+	tests := []struct {
+		name    string
+		url     string
+		maxSize int64
+		wantErr error
+	}{
+		{"too large", server.URL + "/page", 3, ErrTooLarge},
+		{"not http", "file:///etc/passwd", 1024, ErrHostNotAllowed},
+		{"redirect to another scheme", server.URL + "/file", 1024, ErrHostNotAllowed},
+		{"too many redirects", server.URL + "/loop", 1024, nil},
+		{"not found", server.URL + "/missing", 1024, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := fetcher.Get(context.Background(), test.url, test.maxSize)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if test.wantErr != nil && !errors.Is(err, test.wantErr) {
+				t.Errorf("error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
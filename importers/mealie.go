@@ -0,0 +1,202 @@
+package importers
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/ingredients"
+)
+
+// A value given either as a name or as an object with one,
+// as older Mealie versions use plain strings for tags, units and foods
+type mealieName string
+
+func (n *mealieName) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*n = mealieName(schemaText(value))
+	return nil
+}
+
+type mealieIngredient struct {
+	Title        string     `json:"title"`
+	Note         string     `json:"note"`
+	Display      string     `json:"display"`
+	OriginalText string     `json:"originalText"`
+	Quantity     float32    `json:"quantity"`
+	Unit         mealieName `json:"unit"`
+	Food         mealieName `json:"food"`
+}
+
+func (i *mealieIngredient) UnmarshalJSON(data []byte) error {
+	// older versions list ingredients as plain text
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*i = mealieIngredient{Note: text}
+		return nil
+	}
+	type ingredient mealieIngredient
+	return json.Unmarshal(data, (*ingredient)(i))
+}
+
+func (i *mealieIngredient) intoIngredient() db.RecipeIngredient {
+	if food := cleanText(string(i.Food)); food != "" {
+		ingredient := db.RecipeIngredient{
+			Name:     food,
+			Amount:   i.Quantity,
+			UnitType: cleanText(string(i.Unit)),
+		}
+		if note := cleanText(i.Note); note != "" {
+			ingredient.Description = &note
+		}
+		return ingredient
+	}
+	// ingredients Mealie hasn't parsed only have their text
+	for _, text := range []string{i.OriginalText, i.Display, i.Note} {
+		if ingredient, ok := ingredients.ParseLine(singleLine(cleanText(text))); ok {
+			return ingredient
+		}
+	}
+	return db.RecipeIngredient{}
+}
+
+type mealieText struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+// A recipe in a Mealie export, only the fields that are used
+type mealieRecipe struct {
+	Name               string             `json:"name"`
+	Description        string             `json:"description"`
+	RecipeYield        any                `json:"recipeYield"`
+	RecipeServings     float64            `json:"recipeServings"`
+	PrepTime           any                `json:"prepTime"`
+	PerformTime        any                `json:"performTime"`
+	CookTime           any                `json:"cookTime"`
+	TotalTime          any                `json:"totalTime"`
+	RecipeIngredient   []mealieIngredient `json:"recipeIngredient"`
+	RecipeInstructions []mealieText       `json:"recipeInstructions"`
+	Tags               []mealieName       `json:"tags"`
+	RecipeCategory     []mealieName       `json:"recipeCategory"`
+	Notes              []mealieText       `json:"notes"`
+	OrgURL             string             `json:"orgURL"`
+}
+
+func (m *mealieRecipe) intoImportedRecipe() ImportedRecipe {
+	var labels []any
+	for _, label := range append(m.RecipeCategory, m.Tags...) {
+		labels = append(labels, string(label))
+	}
+	recipe := db.CreateRecipe{
+		Title:  recipeTitle(m.Name),
+		Labels: schemaLabels(labels),
+	}
+	setDescription(&recipe, cleanText(m.Description))
+	for _, note := range m.Notes {
+		if text := cleanText(note.Text); text != "" {
+			if title := singleLine(cleanText(note.Title)); title != "" {
+				text = title + "\n" + text
+			}
+			appendNotes(&recipe, text)
+		}
+	}
+
+	// a title marks the first ingredient of a section
+	var section *string
+	for _, ingredient := range m.RecipeIngredient {
+		if title := truncate(singleLine(cleanText(ingredient.Title)), 60); title != "" {
+			section = &title
+		}
+		if ingredient := ingredient.intoIngredient(); ingredient.Name != "" {
+			ingredient.Section = section
+			recipe.Ingredients = append(recipe.Ingredients, ingredient)
+		}
+	}
+	for _, instruction := range m.RecipeInstructions {
+		text := cleanText(instruction.Text)
+		if text == "" {
+			continue
+		}
+		step := db.RecipeStep{Description: text}
+		if title := truncate(singleLine(cleanText(instruction.Title)), 60); title != "" {
+			step.Title = &title
+		}
+		recipe.Steps = append(recipe.Steps, step)
+	}
+
+	recipe.Info.Yields = schemaYields(m.RecipeYield)
+	if recipe.Info.Yields == nil && m.RecipeServings > 0 {
+		recipe.Info.Yields = schemaYields(m.RecipeServings)
+	}
+	recipe.Info.PrepTime = schemaMinutes(m.PrepTime)
+	recipe.Info.CookTime = schemaMinutes(m.PerformTime)
+	if recipe.Info.CookTime == 0 {
+		recipe.Info.CookTime = schemaMinutes(m.CookTime)
+	}
+	if totalTime := schemaMinutes(m.TotalTime); recipe.Info.CookTime == 0 && totalTime > recipe.Info.PrepTime {
+		recipe.Info.CookTime = totalTime - recipe.Info.PrepTime
+	}
+	if source := strings.TrimSpace(m.OrgURL); source != "" {
+		recipe.Info.Source = &source
+	}
+
+	return ImportedRecipe{Recipe: recipe}
+}
+
+// Read the recipes in a Mealie JSON file, holding either one recipe or a list of them
+func readMealieFile(name string, content []byte) []ArchiveEntry {
+	var recipes []mealieRecipe
+	if err := json.Unmarshal(content, &recipes); err != nil {
+		var recipe mealieRecipe
+		if err := json.Unmarshal(content, &recipe); err != nil {
+			return []ArchiveEntry{{Name: baseName(name), Err: err}}
+		}
+		recipes = []mealieRecipe{recipe}
+	}
+
+	entries := make([]ArchiveEntry, len(recipes))
+	for i, recipe := range recipes {
+		entries[i].Name = recipe.Name
+		if recipe.Name == "" {
+			entries[i].Name = baseName(name)
+			entries[i].Err = ErrNoRecipe
+			continue
+		}
+		entries[i].Recipe = recipe.intoImportedRecipe()
+	}
+	return entries
+}
+
+// Read a Mealie export, either a JSON file or a zip where each recipe
+// has a directory holding its JSON and an images directory
+func readMealie(filename string, content []byte, limit *extractLimit) ([]ArchiveEntry, error) {
+	if !isZip(content) {
+		entries := readMealieFile(filename, content)
+		if len(entries) == 1 && entries[0].Err != nil {
+			return nil, invalidArchive(entries[0].Err)
+		}
+		return entries, nil
+	}
+
+	files, err := readZip(content, limit)
+	if err != nil {
+		return nil, err
+	}
+	var entries []ArchiveEntry
+	for _, file := range files {
+		if !hasExtension(file.Name, ".json") {
+			continue
+		}
+		fileEntries := readMealieFile(file.Name, file.Content)
+		if len(fileEntries) == 1 && fileEntries[0].Err == nil {
+			fileEntries[0].Recipe.Image = findImage(files, path.Join(path.Dir(file.Name), "images"), "original", "min-original")
+		}
+		entries = append(entries, fileEntries...)
+	}
+	return entries, nil
+}
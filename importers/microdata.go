@@ -0,0 +1,164 @@
+package importers
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Elements that start a new line when getting an element's text
+var blockElements = map[atom.Atom]bool{
+	atom.Br:  true,
+	atom.P:   true,
+	atom.Div: true,
+	atom.Li:  true,
+	atom.H1:  true,
+	atom.H2:  true,
+	atom.H3:  true,
+	atom.H4:  true,
+	atom.H5:  true,
+	atom.H6:  true,
+	atom.Tr:  true,
+}
+
+func getAttr(node *html.Node, name string) (string, bool) {
+	for _, attr := range node.Attr {
+		if attr.Key == name {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// Get the text of a node, with block elements on their own lines
+func textContent(node *html.Node) string {
+	var builder strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			builder.WriteString(n.Data)
+		case html.ElementNode:
+			if n.DataAtom == atom.Script || n.DataAtom == atom.Style {
+				return
+			}
+			if blockElements[n.DataAtom] {
+				builder.WriteString("\n")
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+		if n.Type == html.ElementNode && blockElements[n.DataAtom] {
+			builder.WriteString("\n")
+		}
+	}
+	walk(node)
+	return builder.String()
+}
+
+// Find all nodes matching a predicate, not searching inside matches
+func findNodes(node *html.Node, match func(*html.Node) bool) []*html.Node {
+	var found []*html.Node
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if match(n) {
+			found = append(found, n)
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+	return found
+}
+
+func isRecipeItem(node *html.Node) bool {
+	if node.Type != html.ElementNode {
+		return false
+	}
+	if _, ok := getAttr(node, "itemscope"); !ok {
+		return false
+	}
+	itemType, _ := getAttr(node, "itemtype")
+	for _, t := range strings.Fields(itemType) {
+		if isRecipeType(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Get the value of a microdata property element
+func itemPropValue(node *html.Node) any {
+	if _, ok := getAttr(node, "itemscope"); ok {
+		return readItem(node)
+	}
+	if content, ok := getAttr(node, "content"); ok {
+		return content
+	}
+	var attr string
+	switch node.DataAtom {
+	case atom.A, atom.Link, atom.Area:
+		attr = "href"
+	case atom.Img, atom.Source, atom.Video, atom.Audio, atom.Iframe, atom.Embed:
+		attr = "src"
+	case atom.Object:
+		attr = "data"
+	case atom.Time:
+		attr = "datetime"
+	case atom.Data, atom.Meter:
+		attr = "value"
+	}
+	if attr != "" {
+		if value, ok := getAttr(node, attr); ok {
+			return value
+		}
+	}
+	return textContent(node)
+}
+
+// Read the properties of a microdata item, in the same shape as JSON-LD
+func readItem(item *html.Node) map[string]any {
+	properties := map[string]any{}
+	if itemType, ok := getAttr(item, "itemtype"); ok {
+		properties["@type"] = itemType
+	}
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			if names, ok := getAttr(child, "itemprop"); ok {
+				value := itemPropValue(child)
+				for _, name := range strings.Fields(names) {
+					switch existing := properties[name].(type) {
+					case nil:
+						properties[name] = value
+					case []any:
+						properties[name] = append(existing, value)
+					default:
+						properties[name] = []any{existing, value}
+					}
+				}
+			}
+			// properties inside a nested item belong to that item
+			if _, ok := getAttr(child, "itemscope"); !ok {
+				walk(child)
+			}
+		}
+	}
+	walk(item)
+	return properties
+}
+
+// Find the first schema.org Recipe described with microdata
+func findMicrodataRecipe(document *html.Node) map[string]any {
+	if items := findNodes(document, isRecipeItem); len(items) != 0 {
+		return readItem(items[0])
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package importers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/ingredients"
+)
+
+// A recipe in a Paprika export, only the fields that are used
+type paprikaRecipe struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Notes       string   `json:"notes"`
+	Ingredients string   `json:"ingredients"`
+	Directions  string   `json:"directions"`
+	Servings    string   `json:"servings"`
+	PrepTime    string   `json:"prep_time"`
+	CookTime    string   `json:"cook_time"`
+	TotalTime   string   `json:"total_time"`
+	Source      string   `json:"source"`
+	SourceURL   string   `json:"source_url"`
+	Categories  []string `json:"categories"`
+	PhotoData   string   `json:"photo_data"`
+}
+
+func (p *paprikaRecipe) intoImportedRecipe() ImportedRecipe {
+	recipe := db.CreateRecipe{
+		Title:  recipeTitle(p.Name),
+		Labels: schemaLabels(toAnySlice(p.Categories)),
+	}
+	setDescription(&recipe, cleanText(p.Description))
+	appendNotes(&recipe, cleanText(p.Notes))
+
+	recipe.Ingredients = ingredients.ParseText(cleanText(p.Ingredients))
+	for _, line := range strings.Split(cleanText(p.Directions), "\n") {
+		if line != "" {
+			recipe.Steps = append(recipe.Steps, db.RecipeStep{Description: line})
+		}
+	}
+
+	recipe.Info.Yields = schemaYields(p.Servings)
+	recipe.Info.PrepTime = textMinutes(p.PrepTime)
+	recipe.Info.CookTime = textMinutes(p.CookTime)
+	if totalTime := textMinutes(p.TotalTime); recipe.Info.CookTime == 0 && totalTime > recipe.Info.PrepTime {
+		recipe.Info.CookTime = totalTime - recipe.Info.PrepTime
+	}
+	if source := strings.TrimSpace(p.SourceURL); source != "" {
+		recipe.Info.Source = &source
+	} else if source := strings.TrimSpace(p.Source); source != "" {
+		recipe.Info.Source = &source
+	}
+
+	imported := ImportedRecipe{Recipe: recipe}
+	if p.PhotoData != "" {
+		// a broken photo shouldn't stop the recipe being imported
+		imported.Image, _ = base64.StdEncoding.DecodeString(p.PhotoData)
+	}
+	return imported
+}
+
+func toAnySlice[T any](values []T) []any {
+	items := make([]any, len(values))
+	for i, value := range values {
+		items[i] = value
+	}
+	return items
+}
+
+func readPaprikaRecipe(name string, content []byte, limit *extractLimit) ArchiveEntry {
+	entry := ArchiveEntry{Name: baseName(name)}
+	content, err := gunzip(content, limit)
+	if err != nil {
+		entry.Err = err
+		return entry
+	}
+	var recipe paprikaRecipe
+	if err := json.Unmarshal(content, &recipe); err != nil {
+		entry.Err = err
+		return entry
+	}
+	if recipe.Name != "" {
+		entry.Name = recipe.Name
+	}
+	entry.Recipe = recipe.intoImportedRecipe()
+	return entry
+}
+
+// Read a .paprikarecipes export, a zip of gzipped JSON recipes.
+// A single exported .paprikarecipe is also accepted
+func readPaprika(filename string, content []byte, limit *extractLimit) ([]ArchiveEntry, error) {
+	if !isZip(content) {
+		entry := readPaprikaRecipe(filename, content, limit)
+		if entry.Err != nil {
+			return nil, invalidArchive(entry.Err)
+		}
+		return []ArchiveEntry{entry}, nil
+	}
+
+	files, err := readZip(content, limit)
+	if err != nil {
+		return nil, err
+	}
+	var entries []ArchiveEntry
+	for _, file := range files {
+		if hasExtension(file.Name, ".paprikarecipe") {
+			entries = append(entries, readPaprikaRecipe(file.Name, file.Content, limit))
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,412 @@
+package importers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/my-cooking-codex/api/core"
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/ingredients"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"gorm.io/datatypes"
+)
+
+var ErrNoRecipe = errors.New("no recipe found")
+
+// A recipe found by an importer, ready to be created
+type ImportedRecipe struct {
+	Recipe   db.CreateRecipe
+	ImageURL *string
+	// image content, when it was included with the recipe
+	Image []byte
+}
+
+var (
+	tagRegex        = regexp.MustCompile(`<[^>]*>`)
+	blockTagRegex   = regexp.MustCompile(`(?i)</?(?:br|p|div|li|h[1-6]|tr)\b[^>]*>`)
+	whitespaceRegex = regexp.MustCompile(`[ \t\r\f\v]+`)
+	numberRegex     = regexp.MustCompile(`\d+`)
+	rangeEndRegex   = regexp.MustCompile(`^\s*[-–]\s*\d+`)
+	// durations written out, such as "1 hr 30 mins"
+	textDurationRegex = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*(days?|d|hours?|hrs?|h|minutes?|mins?|m|seconds?|secs?|s)\b`)
+)
+
+func isRecipeType(t string) bool {
+	t = strings.TrimSuffix(strings.TrimSpace(t), "/")
+	return t == "Recipe" ||
+		t == "schema:Recipe" ||
+		strings.HasSuffix(t, "://schema.org/Recipe")
+}
+
+func hasRecipeType(value any) bool {
+	switch t := value.(type) {
+	case string:
+		return isRecipeType(t)
+	case []any:
+		for _, item := range t {
+			if s, ok := item.(string); ok && isRecipeType(s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Search decoded JSON-LD for a Recipe node, including inside @graph
+func findJSONLDRecipe(value any) map[string]any {
+	switch v := value.(type) {
+	case map[string]any:
+		if hasRecipeType(v["@type"]) {
+			return v
+		}
+		for _, key := range []string{"@graph", "mainEntity", "mainEntityOfPage"} {
+			if recipe := findJSONLDRecipe(v[key]); recipe != nil {
+				return recipe
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if recipe := findJSONLDRecipe(item); recipe != nil {
+				return recipe
+			}
+		}
+	}
+	return nil
+}
+
+func isJSONLDScript(node *html.Node) bool {
+	if node.Type != html.ElementNode || node.DataAtom != atom.Script {
+		return false
+	}
+	scriptType, _ := getAttr(node, "type")
+	return strings.EqualFold(strings.TrimSpace(scriptType), "application/ld+json")
+}
+
+// Clean up text that may contain markup, entities or extra whitespace
+func cleanText(value string) string {
+	// block tags break lines, inline ones such as <b> are part of a sentence
+	value = blockTagRegex.ReplaceAllString(value, "\n")
+	value = html.UnescapeString(tagRegex.ReplaceAllString(value, ""))
+	lines := strings.Split(value, "\n")
+	cleaned := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line = strings.TrimSpace(whitespaceRegex.ReplaceAllString(line, " ")); line != "" {
+			cleaned = append(cleaned, line)
+		}
+	}
+	return strings.Join(cleaned, "\n")
+}
+
+func singleLine(value string) string {
+	return strings.ReplaceAll(value, "\n", " ")
+}
+
+func truncate(value string, maxLength int) string {
+	if utf8.RuneCountInString(value) <= maxLength {
+		return value
+	}
+	return strings.TrimSpace(string([]rune(value)[:maxLength]))
+}
+
+// Get a single piece of text from a schema value
+func schemaText(value any) string {
+	switch v := value.(type) {
+	case string:
+		return cleanText(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []any:
+		for _, item := range v {
+			if text := schemaText(item); text != "" {
+				return text
+			}
+		}
+	case map[string]any:
+		for _, key := range []string{"@value", "text", "name"} {
+			if text := schemaText(v[key]); text != "" {
+				return text
+			}
+		}
+	}
+	return ""
+}
+
+// Get every piece of text from a schema value
+func schemaTexts(value any) []string {
+	if items, ok := value.([]any); ok {
+		texts := make([]string, 0, len(items))
+		for _, item := range items {
+			if text := schemaText(item); text != "" {
+				texts = append(texts, text)
+			}
+		}
+		return texts
+	} else if text := schemaText(value); text != "" {
+		return []string{text}
+	}
+	return nil
+}
+
+// Get a url from a schema value, resolved against the page's url
+func schemaURL(value any, base *url.URL) *string {
+	var raw string
+	switch v := value.(type) {
+	case string:
+		raw = strings.TrimSpace(v)
+	case []any:
+		for _, item := range v {
+			if u := schemaURL(item, base); u != nil {
+				return u
+			}
+		}
+	case map[string]any:
+		for _, key := range []string{"url", "contentUrl", "@id"} {
+			if u := schemaURL(v[key], base); u != nil {
+				return u
+			}
+		}
+	}
+	if raw == "" {
+		return nil
+	}
+	u, err := base.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil
+	}
+	resolved := u.String()
+	return &resolved
+}
+
+// Get minutes from a duration written out, a plain number is taken as minutes
+func textMinutes(value string) uint {
+	if minutes, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && minutes > 0 {
+		return uint(math.Round(minutes))
+	}
+	var minutes float64
+	for _, match := range textDurationRegex.FindAllStringSubmatch(value, -1) {
+		amount, err := strconv.ParseFloat(match[1], 64)
+		if err != nil {
+			continue
+		}
+		switch unit := strings.ToLower(match[2]); unit[0] {
+		case 'd':
+			minutes += amount * 24 * 60
+		case 'h':
+			minutes += amount * 60
+		case 'm':
+			minutes += amount
+		case 's':
+			minutes += amount / 60
+		}
+	}
+	return uint(math.Round(minutes))
+}
+
+// Get a duration in minutes from an ISO-8601 schema value,
+// falling back to a duration written out as some sites do
+func schemaMinutes(value any) uint {
+	text := schemaText(value)
+	duration, err := core.ParseISO8601Duration(text)
+	if err != nil {
+		return textMinutes(text)
+	} else if duration <= 0 {
+		return 0
+	}
+	return uint(math.Round(duration.Minutes()))
+}
+
+// Get the yield from values like 4, "4 servings" or "Makes 12 cookies"
+func schemaYields(value any) *datatypes.JSONType[db.RecipeInfoYields] {
+	for _, text := range schemaTexts(value) {
+		text = singleLine(text)
+		location := numberRegex.FindStringIndex(text)
+		if location == nil {
+			continue
+		}
+		amount, err := strconv.ParseUint(text[location[0]:location[1]], 10, 32)
+		if err != nil || amount == 0 {
+			continue
+		}
+		// a range such as "4-6 servings" uses the lower amount
+		unitType := strings.TrimSpace(rangeEndRegex.ReplaceAllString(text[location[1]:], ""))
+		if unitType == "" {
+			unitType = "servings"
+		}
+		yields := datatypes.NewJSONType(db.RecipeInfoYields{
+			Value:    uint(amount),
+			UnitType: truncate(unitType, 60),
+		})
+		return &yields
+	}
+	return nil
+}
+
+// Get the steps from the many shapes recipeInstructions can take
+func schemaSteps(value any) []db.RecipeStep {
+	var steps []db.RecipeStep
+	switch v := value.(type) {
+	case string:
+		for _, line := range strings.Split(cleanText(v), "\n") {
+			if line != "" {
+				steps = append(steps, db.RecipeStep{Description: line})
+			}
+		}
+	case []any:
+		for _, item := range v {
+			steps = append(steps, schemaSteps(item)...)
+		}
+	case map[string]any:
+		if list, ok := v["itemListElement"]; ok {
+			// a HowToSection, its name is the section of its steps
+			steps = schemaSteps(list)
+			if name := schemaText(v["name"]); name != "" {
+				section := truncate(name, 60)
+				for i := range steps {
+					if steps[i].Section == nil {
+						steps[i].Section = &section
+					}
+				}
+			}
+			return steps
+		}
+		text := schemaText(v["text"])
+		name := schemaText(v["name"])
+		if text == "" {
+			text, name = name, ""
+		}
+		if text == "" {
+			return nil
+		}
+		step := db.RecipeStep{Description: text}
+		if name != "" && !strings.HasPrefix(text, strings.TrimRight(name, ".…")) {
+			title := truncate(name, 60)
+			step.Title = &title
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// Get labels from keywords, given either as a list or comma separated
+func schemaLabels(value any) []string {
+	var labels []string
+	seen := map[string]bool{}
+	for _, text := range schemaTexts(value) {
+		for _, keyword := range strings.Split(singleLine(text), ",") {
+			keyword = truncate(strings.TrimSpace(keyword), 60)
+			if keyword == "" || seen[strings.ToLower(keyword)] {
+				continue
+			}
+			seen[strings.ToLower(keyword)] = true
+			labels = append(labels, keyword)
+		}
+	}
+	return labels
+}
+
+// Use a description as the short description when it fits, otherwise as the long one
+func setDescription(recipe *db.CreateRecipe, description string) {
+	if description == "" {
+		return
+	}
+	if utf8.RuneCountInString(description) <= 256 {
+		recipe.ShortDescription = &description
+	} else {
+		recipe.LongDescription = &description
+	}
+}
+
+// Add notes to the end of the long description
+func appendNotes(recipe *db.CreateRecipe, notes string) {
+	if notes == "" {
+		return
+	}
+	if recipe.LongDescription != nil {
+		notes = *recipe.LongDescription + "\n\n" + notes
+	}
+	recipe.LongDescription = &notes
+}
+
+// Get a recipe's title, with a placeholder when there isn't one
+func recipeTitle(title string) string {
+	if title = truncate(singleLine(cleanText(title)), 60); title == "" {
+		return "Imported recipe"
+	}
+	return title
+}
+
+// Map a schema.org Recipe onto a recipe that can be created
+func recipeFromSchema(schema map[string]any, pageURL *url.URL) ImportedRecipe {
+	recipe := db.CreateRecipe{
+		Title: recipeTitle(schemaText(schema["name"])),
+	}
+
+	setDescription(&recipe, schemaText(schema["description"]))
+
+	ingredientsValue := schema["recipeIngredient"]
+	if ingredientsValue == nil {
+		ingredientsValue = schema["ingredients"]
+	}
+	for _, line := range schemaTexts(ingredientsValue) {
+		if ingredient, ok := ingredients.ParseLine(singleLine(line)); ok {
+			recipe.Ingredients = append(recipe.Ingredients, ingredient)
+		}
+	}
+	recipe.Steps = schemaSteps(schema["recipeInstructions"])
+
+	recipe.Info.Yields = schemaYields(schema["recipeYield"])
+	recipe.Info.PrepTime = schemaMinutes(schema["prepTime"])
+	recipe.Info.CookTime = schemaMinutes(schema["cookTime"])
+	if totalTime := schemaMinutes(schema["totalTime"]); recipe.Info.CookTime == 0 && totalTime > recipe.Info.PrepTime {
+		recipe.Info.CookTime = totalTime - recipe.Info.PrepTime
+	}
+	if source := schemaURL(schema["url"], pageURL); source != nil {
+		recipe.Info.Source = source
+	} else {
+		source := pageURL.String()
+		recipe.Info.Source = &source
+	}
+
+	recipe.Labels = schemaLabels(schema["keywords"])
+
+	return ImportedRecipe{
+		Recipe:   recipe,
+		ImageURL: schemaURL(schema["image"], pageURL),
+	}
+}
+
+// Find a schema.org Recipe in a page, from either JSON-LD or microdata
+func RecipeFromHTML(page []byte, pageURL *url.URL) (ImportedRecipe, error) {
+	document, err := html.Parse(bytes.NewReader(page))
+	if err != nil {
+		return ImportedRecipe{}, err
+	}
+
+	for _, script := range findNodes(document, isJSONLDScript) {
+		var value any
+		if script.FirstChild == nil {
+			continue
+		}
+		if err := json.Unmarshal([]byte(script.FirstChild.Data), &value); err != nil {
+			// sites often have broken JSON-LD, so try the next one
+			continue
+		}
+		if schema := findJSONLDRecipe(value); schema != nil {
+			return recipeFromSchema(schema, pageURL), nil
+		}
+	}
+
+	if schema := findMicrodataRecipe(document); schema != nil {
+		return recipeFromSchema(schema, pageURL), nil
+	}
+
+	return ImportedRecipe{}, ErrNoRecipe
+}
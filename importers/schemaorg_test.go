@@ -0,0 +1,223 @@
+package importers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/my-cooking-codex/api/db"
+	"gorm.io/datatypes"
+)
+
+const testPageURL = "https://example.com/recipes/pancakes"
+
+func stringPtr(value string) *string {
+	return &value
+}
+
+// The recipe every test page describes
+func expectedPancakes() ImportedRecipe {
+	yields := datatypes.NewJSONType(db.RecipeInfoYields{Value: 4, UnitType: "pancakes"})
+	return ImportedRecipe{
+		Recipe: db.CreateRecipe{
+			Title:            "Pancakes",
+			ShortDescription: stringPtr("Fluffy pancakes."),
+			Info: db.CreateRecipeInfo{
+				Yields:   &yields,
+				PrepTime: 10,
+				CookTime: 20,
+				Source:   stringPtr(testPageURL),
+			},
+			Ingredients: []db.RecipeIngredient{
+				{Name: "flour", Amount: 200, UnitType: "g"},
+				{Name: "milk", Amount: 2, UnitType: "cup"},
+			},
+			Steps: []db.RecipeStep{
+				{Description: "Mix everything."},
+				{Description: "Fry in a pan.", Title: stringPtr("Cook")},
+			},
+			Labels: []string{"breakfast", "easy"},
+		},
+		ImageURL: stringPtr("https://example.com/images/pancakes.jpg"),
+	}
+}
+
+const pancakesJSONLD = `{
+	"@context": "https://schema.org",
+	"@type": "Recipe",
+	"name": "Pancakes",
+	"description": "Fluffy <b>pancakes</b>.",
+	"image": {"@type": "ImageObject", "url": "/images/pancakes.jpg"},
+	"recipeYield": ["Makes 4 pancakes"],
+	"prepTime": "PT10M",
+	"totalTime": "PT30M",
+	"recipeIngredient": ["200g flour", "2 cups milk"],
+	"recipeInstructions": [
+		{"@type": "HowToStep", "text": "Mix everything."},
+		{"@type": "HowToStep", "name": "Cook", "text": "Fry in a pan."}
+	],
+	"keywords": "breakfast, easy, Breakfast"
+}`
+
+func TestRecipeFromHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+	}{
+		{
+			name: "json-ld",
+			page: `<html><head><script type="application/ld+json">` + pancakesJSONLD + `</script></head></html>`,
+		},
+		{
+			name: "json-ld graph",
+			page: `<script type="application/ld+json">{
+				"@context": "https://schema.org",
+				"@graph": [
+					{"@type": "WebSite", "name": "Example"},
+					{"@type": "WebPage", "name": "Pancakes page"},
+					` + pancakesJSONLD + `
+				]
+			}</script>`,
+		},
+		{
+			name: "json-ld array",
+			page: `<script type="application/ld+json">[
+				{"@type": "BreadcrumbList"},
+				` + pancakesJSONLD + `
+			]</script>`,
+		},
+		{
+			name: "json-ld after broken json-ld",
+			page: `<script type="application/ld+json">{"@type": "Recipe",</script>
+				<script type="application/ld+json">` + pancakesJSONLD + `</script>`,
+		},
+		{
+			name: "microdata",
+			page: `<html><body>
+				<div itemscope itemtype="https://schema.org/Recipe">
+					<h1 itemprop="name">Pancakes</h1>
+					<img itemprop="image" src="/images/pancakes.jpg">
+					<p itemprop="description">Fluffy <b>pancakes</b>.</p>
+					<meta itemprop="recipeYield" content="4 pancakes">
+					<time itemprop="prepTime" datetime="PT10M">10 minutes</time>
+					<time itemprop="cookTime" datetime="PT20M">20 minutes</time>
+					<ul>
+						<li itemprop="recipeIngredient">200g flour</li>
+						<li itemprop="recipeIngredient">2 cups milk</li>
+					</ul>
+					<ol>
+						<li itemprop="recipeInstructions">Mix everything.</li>
+						<li itemprop="recipeInstructions" itemscope itemtype="https://schema.org/HowToStep">
+							<span itemprop="name">Cook</span>
+							<span itemprop="text">Fry in a pan.</span>
+						</li>
+					</ol>
+					<meta itemprop="keywords" content="breakfast,easy">
+					<div itemprop="author" itemscope itemtype="https://schema.org/Person">
+						<span itemprop="name">Someone</span>
+					</div>
+				</div>
+			</body></html>`,
+		},
+	}
+	pageURL, _ := url.Parse(testPageURL)
+	want := expectedPancakes()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := RecipeFromHTML([]byte(test.page), pageURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// compared as JSON so pointers are followed when printed
+			gotJSON, _ := json.Marshal(got.Recipe)
+			wantJSON, _ := json.Marshal(want.Recipe)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("recipe = %s\nwant %s", gotJSON, wantJSON)
+			}
+			if got.ImageURL == nil || *got.ImageURL != *want.ImageURL {
+				t.Errorf("image url = %v, want %s", got.ImageURL, *want.ImageURL)
+			}
+		})
+	}
+}
+
+func TestRecipeFromHTMLNoRecipe(t *testing.T) {
+	pageURL, _ := url.Parse(testPageURL)
+	for _, page := range []string{
+		`<html><body><h1>Pancakes</h1></body></html>`,
+		`<script type="application/ld+json">{"@type": "Article", "name": "Pancakes"}</script>`,
+		`<div itemscope itemtype="https://schema.org/Person"><span itemprop="name">Someone</span></div>`,
+	} {
+		if _, err := RecipeFromHTML([]byte(page), pageURL); !errors.Is(err, ErrNoRecipe) {
+			t.Errorf("error = %v, want ErrNoRecipe for %s", err, page)
+		}
+	}
+}
+
+func TestCleanText(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"Fluffy <b>pancakes</b>.", "Fluffy pancakes."},
+		{"Mix<br>well", "Mix\nwell"},
+		{"<p>One</p><p>Two</p>", "One\nTwo"},
+		{"  salt &amp;\tpepper  ", "salt & pepper"},
+		{"<div>\n\n</div>", ""},
+	}
+	for _, test := range tests {
+		if got := cleanText(test.value); got != test.want {
+			t.Errorf("cleanText(%q) = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
+
+func TestSchemaSteps(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []db.RecipeStep
+	}{
+		{"text", `"Mix.<br>Bake."`, []db.RecipeStep{{Description: "Mix."}, {Description: "Bake."}}},
+		{"empty text", `"<p></p>"`, nil},
+		{"blank texts", `["", " ", "Mix."]`, []db.RecipeStep{{Description: "Mix."}}},
+		{
+			"steps",
+			`[{"@type": "HowToStep", "text": "Mix."}, {"@type": "HowToStep", "name": "Bake", "text": "In the oven."}]`,
+			[]db.RecipeStep{{Description: "Mix."}, {Description: "In the oven.", Title: stringPtr("Bake")}},
+		},
+		{"name only", `[{"@type": "HowToStep", "name": "Mix."}]`, []db.RecipeStep{{Description: "Mix."}}},
+		{
+			"sections",
+			`[
+				{"@type": "HowToSection", "name": "Batter", "itemListElement": [{"@type": "HowToStep", "text": "Mix."}]},
+				{"@type": "HowToSection", "itemListElement": [{"@type": "HowToStep", "text": "Rest."}]},
+				{"@type": "HowToSection", "name": "Cook", "itemListElement": [
+					{"@type": "HowToStep", "text": "Heat the pan."},
+					{"@type": "HowToSection", "name": "Toppings", "itemListElement": ["Add syrup."]}
+				]}
+			]`,
+			[]db.RecipeStep{
+				{Description: "Mix.", Section: stringPtr("Batter")},
+				{Description: "Rest."},
+				{Description: "Heat the pan.", Section: stringPtr("Cook")},
+				{Description: "Add syrup.", Section: stringPtr("Toppings")},
+			},
+		},
+		{"name repeats text", `[{"@type": "HowToStep", "name": "Mix...", "text": "Mix well."}]`, []db.RecipeStep{{Description: "Mix well."}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var value any
+			if err := json.Unmarshal([]byte(test.value), &value); err != nil {
+				t.Fatal(err)
+			}
+			got, _ := json.Marshal(schemaSteps(value))
+			want, _ := json.Marshal(test.want)
+			if string(got) != string(want) {
+				t.Errorf("steps = %s, want %s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package importers
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+
+	"github.com/my-cooking-codex/api/db"
+	"gorm.io/datatypes"
+)
+
+type tandoorName struct {
+	Name string `json:"name"`
+}
+
+type tandoorIngredient struct {
+	Food     *tandoorName `json:"food"`
+	Unit     *tandoorName `json:"unit"`
+	Amount   float32      `json:"amount"`
+	Note     string       `json:"note"`
+	IsHeader bool         `json:"is_header"`
+	NoAmount bool         `json:"no_amount"`
+}
+
+type tandoorStep struct {
+	Name        string              `json:"name"`
+	Instruction string              `json:"instruction"`
+	Ingredients []tandoorIngredient `json:"ingredients"`
+}
+
+// A recipe in a Tandoor export, only the fields that are used
+type tandoorRecipe struct {
+	Name         string        `json:"name"`
+	Description  string        `json:"description"`
+	Keywords     []tandoorName `json:"keywords"`
+	WorkingTime  uint          `json:"working_time"`
+	WaitingTime  uint          `json:"waiting_time"`
+	Servings     uint          `json:"servings"`
+	ServingsText string        `json:"servings_text"`
+	SourceURL    string        `json:"source_url"`
+	Steps        []tandoorStep `json:"steps"`
+}
+
+func (t *tandoorRecipe) intoImportedRecipe() ImportedRecipe {
+	var labels []any
+	for _, keyword := range t.Keywords {
+		labels = append(labels, keyword.Name)
+	}
+	recipe := db.CreateRecipe{
+		Title:  recipeTitle(t.Name),
+		Labels: schemaLabels(labels),
+	}
+	setDescription(&recipe, cleanText(t.Description))
+
+	var section *string
+	for _, step := range t.Steps {
+		for _, ingredient := range step.Ingredients {
+			// headers start a section for the ingredients that follow
+			if ingredient.IsHeader {
+				section = nil
+				if heading := truncate(singleLine(cleanText(ingredient.Note)), 60); heading != "" {
+					section = &heading
+				}
+				continue
+			} else if ingredient.Food == nil {
+				continue
+			}
+			name := singleLine(cleanText(ingredient.Food.Name))
+			if name == "" {
+				continue
+			}
+			newIngredient := db.RecipeIngredient{Name: name, Section: section}
+			if !ingredient.NoAmount {
+				newIngredient.Amount = ingredient.Amount
+				if ingredient.Unit != nil {
+					newIngredient.UnitType = singleLine(cleanText(ingredient.Unit.Name))
+				}
+			}
+			if note := singleLine(cleanText(ingredient.Note)); note != "" {
+				newIngredient.Description = &note
+			}
+			recipe.Ingredients = append(recipe.Ingredients, newIngredient)
+		}
+
+		text := cleanText(step.Instruction)
+		if text == "" {
+			continue
+		}
+		newStep := db.RecipeStep{Description: text}
+		if title := truncate(singleLine(cleanText(step.Name)), 60); title != "" {
+			newStep.Title = &title
+		}
+		recipe.Steps = append(recipe.Steps, newStep)
+	}
+
+	if t.Servings != 0 {
+		unitType := truncate(singleLine(cleanText(t.ServingsText)), 60)
+		if unitType == "" {
+			unitType = "servings"
+		}
+		yields := datatypes.NewJSONType(db.RecipeInfoYields{Value: t.Servings, UnitType: unitType})
+		recipe.Info.Yields = &yields
+	}
+	recipe.Info.PrepTime = t.WorkingTime
+	recipe.Info.CookTime = t.WaitingTime
+	if source := strings.TrimSpace(t.SourceURL); source != "" {
+		recipe.Info.Source = &source
+	}
+
+	return ImportedRecipe{Recipe: recipe}
+}
+
+// Read a recipe's files in a Tandoor export, recipe.json and its image
+func readTandoorRecipe(name string, files []archiveFile) ArchiveEntry {
+	entry := ArchiveEntry{Name: baseName(name)}
+	for _, file := range files {
+		if path.Base(file.Name) != "recipe.json" {
+			continue
+		}
+		var recipe tandoorRecipe
+		if err := json.Unmarshal(file.Content, &recipe); err != nil {
+			entry.Err = err
+			return entry
+		}
+		if recipe.Name != "" {
+			entry.Name = recipe.Name
+		}
+		entry.Recipe = recipe.intoImportedRecipe()
+		entry.Recipe.Image = findImage(files, path.Dir(file.Name), "image")
+		return entry
+	}
+	entry.Err = ErrNoRecipe
+	return entry
+}
+
+// Read a Tandoor export, a zip holding a zip for each recipe.
+// A single recipe's zip is also accepted
+func readTandoor(content []byte, limit *extractLimit) ([]ArchiveEntry, error) {
+	files, err := readZip(content, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if file.Name == "recipe.json" {
+			return []ArchiveEntry{readTandoorRecipe("recipe", files)}, nil
+		}
+	}
+
+	var entries []ArchiveEntry
+	for _, file := range files {
+		if !hasExtension(file.Name, ".zip") {
+			continue
+		}
+		if recipeFiles, err := readZip(file.Content, limit); err != nil {
+			entries = append(entries, ArchiveEntry{Name: baseName(file.Name), Err: err})
+		} else {
+			entries = append(entries, readTandoorRecipe(file.Name, recipeFiles))
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,198 @@
+package ingredients
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/units"
+)
+
+var unicodeFractions = map[rune]string{
+	'½': "1/2", '⅓': "1/3", '⅔': "2/3", '¼': "1/4", '¾': "3/4",
+	'⅕': "1/5", '⅖': "2/5", '⅗': "3/5", '⅘': "4/5", '⅙': "1/6", '⅚': "5/6",
+	'⅛': "1/8", '⅜': "3/8", '⅝': "5/8", '⅞': "7/8",
+}
+
+const amountPattern = `(\d+\s+\d+/\d+|\d+/\d+|\d+(?:[.,]\d+)?)`
+
+var (
+	bulletRegex = regexp.MustCompile(`^[-*•·▪]\s*`)
+	// an amount, or a range of them such as "2-3" or "2 to 3"
+	amountRegex        = regexp.MustCompile(`^` + amountPattern + `(?:\s*(?:-|–|—|to|or)\s*` + amountPattern + `)?\s*`)
+	articleRegex       = regexp.MustCompile(`(?i)^an?\s+`)
+	parentheticalRegex = regexp.MustCompile(`\s*\(([^)]*)\)`)
+	ofRegex            = regexp.MustCompile(`(?i)^of\s+`)
+	toTasteRegex       = regexp.MustCompile(`(?i)\s+(to taste|optional|as needed)$`)
+	whitespaceRegex    = regexp.MustCompile(`\s+`)
+)
+
+// Write unicode fractions out, so "2½" becomes "2 1/2"
+func replaceUnicodeFractions(line string) string {
+	var builder strings.Builder
+	var previous rune
+	for _, r := range line {
+		if fraction, ok := unicodeFractions[r]; ok {
+			if previous >= '0' && previous <= '9' {
+				builder.WriteRune(' ')
+			}
+			builder.WriteString(fraction)
+		} else if r == '⁄' {
+			builder.WriteRune('/')
+		} else {
+			builder.WriteRune(r)
+		}
+		previous = r
+	}
+	return builder.String()
+}
+
+// Parse an amount matched by amountPattern, such as "1 1/2", "1/2" or "1,5"
+func parseAmount(value string) float64 {
+	var amount float64
+	for _, part := range strings.Fields(value) {
+		if numerator, denominator, ok := strings.Cut(part, "/"); ok {
+			n, _ := strconv.ParseFloat(numerator, 64)
+			d, _ := strconv.ParseFloat(denominator, 64)
+			if d != 0 {
+				amount += n / d
+			}
+			continue
+		}
+		number, _ := strconv.ParseFloat(strings.Replace(part, ",", ".", 1), 64)
+		amount += number
+	}
+	return amount
+}
+
+// Take a unit from the start of text, trying two words first for units like "fl oz"
+func cutUnit(text string) (string, string, bool) {
+	words := strings.Fields(text)
+	for count := 2; count > 0; count-- {
+		if len(words) < count {
+			continue
+		}
+		if unit, ok := units.Lookup(strings.Join(words[:count], " ")); ok && unit.Name != "" {
+			return unit.Name, strings.Join(words[count:], " "), true
+		}
+	}
+	return "", text, false
+}
+
+// Tidy a line before parsing, writing fractions out and removing any bullet
+func normaliseLine(line string) string {
+	line = whitespaceRegex.ReplaceAllString(replaceUnicodeFractions(line), " ")
+	return strings.TrimSpace(bulletRegex.ReplaceAllString(strings.TrimSpace(line), ""))
+}
+
+// Whether a line is only an amount, optionally with a unit, such as "1 1/2" or "200 g"
+func isAmountOnly(line string) bool {
+	line = normaliseLine(line)
+	match := amountRegex.FindString(line)
+	if match == "" {
+		return false
+	}
+	_, rest, _ := cutUnit(line[len(match):])
+	return strings.TrimSpace(rest) == ""
+}
+
+// Parse a line of free text, such as "2 1/2 cups all-purpose flour, sifted",
+// into an ingredient. Ranges like "2-3" use the lower amount, noting the
+// upper one in the description. False if the line holds no ingredient, which
+// includes lines that are only an amount such as "1 1/2", as there is nothing
+// to name; ParseText joins those to the line after them
+func ParseLine(line string) (db.RecipeIngredient, bool) {
+	line = normaliseLine(line)
+
+	var ingredient db.RecipeIngredient
+	var notes []string
+	rest := line
+	if match := amountRegex.FindStringSubmatch(rest); match != nil {
+		ingredient.Amount = float32(parseAmount(match[1]))
+		if match[2] != "" {
+			notes = append(notes, "up to "+match[2])
+		}
+		rest = rest[len(match[0]):]
+	} else if match := articleRegex.FindString(rest); match != "" {
+		// "a pinch of salt", but not "an apple" as it has no unit
+		if _, _, ok := cutUnit(rest[len(match):]); ok {
+			ingredient.Amount = 1
+			rest = rest[len(match):]
+		}
+	}
+
+	// a size given in brackets after the amount, such as "1 (400g) can"
+	if ingredient.Amount != 0 {
+		if match := parentheticalRegex.FindStringSubmatchIndex(rest); match != nil && match[0] == 0 {
+			notes = append(notes, strings.TrimSpace(rest[match[2]:match[3]]))
+			rest = strings.TrimSpace(rest[match[1]:])
+		}
+		var ok bool
+		if ingredient.UnitType, rest, ok = cutUnit(rest); ok {
+			rest = ofRegex.ReplaceAllString(rest, "")
+		}
+	}
+
+	for _, match := range parentheticalRegex.FindAllStringSubmatch(rest, -1) {
+		if note := strings.TrimSpace(match[1]); note != "" {
+			notes = append(notes, note)
+		}
+	}
+	rest = parentheticalRegex.ReplaceAllString(rest, "")
+	name, description, _ := strings.Cut(rest, ",")
+	if match := toTasteRegex.FindStringSubmatch(name); match != nil {
+		name = name[:len(name)-len(match[0])]
+		notes = append(notes, match[1])
+	}
+	if description = strings.TrimSpace(description); description != "" {
+		notes = append(notes, description)
+	}
+
+	ingredient.Name = strings.TrimSpace(name)
+	if ingredient.Name == "" {
+		return db.RecipeIngredient{}, false
+	}
+	if len(notes) != 0 {
+		description := strings.Join(notes, ", ")
+		ingredient.Description = &description
+	}
+	return ingredient, true
+}
+
+// Parse a block of text into ingredients, one per line. Lines ending in a
+// colon, such as "For the sauce:", start a section for the lines after them.
+// A line that is only an amount is joined to the next, as some sites put the
+// amount and the name on separate lines
+func ParseText(text string) []db.RecipeIngredient {
+	var ingredients []db.RecipeIngredient
+	var section *string
+	var amount string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasSuffix(line, ":") {
+			heading := strings.TrimSpace(bulletRegex.ReplaceAllString(strings.TrimSuffix(line, ":"), ""))
+			section = nil
+			if heading != "" {
+				section = &heading
+			}
+			amount = ""
+			continue
+		}
+		if amount != "" {
+			line = amount + " " + normaliseLine(line)
+			amount = ""
+		} else if isAmountOnly(line) {
+			amount = line
+			continue
+		}
+		if ingredient, ok := ParseLine(line); ok {
+			ingredient.Section = section
+			ingredients = append(ingredients, ingredient)
+		}
+	}
+	return ingredients
+}
@@ -0,0 +1,88 @@
+package ingredients
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/my-cooking-codex/api/core"
+)
+
+// An expected result of parsing a line, from testdata/lines.json
+type lineCase struct {
+	Line          string  `json:"line"`
+	NotIngredient bool    `json:"notIngredient"`
+	Amount        float32 `json:"amount"`
+	UnitType      string  `json:"unitType"`
+	Name          string  `json:"name"`
+	Description   string  `json:"description"`
+}
+
+func TestParseLine(t *testing.T) {
+	content, err := os.ReadFile("testdata/lines.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cases []lineCase
+	if err := json.Unmarshal(content, &cases); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range cases {
+		ingredient, ok := ParseLine(test.Line)
+		if ok == test.NotIngredient {
+			t.Errorf("ParseLine(%q) ok = %v, want %v", test.Line, ok, !test.NotIngredient)
+			continue
+		}
+		if ingredient.Amount != test.Amount ||
+			ingredient.UnitType != test.UnitType ||
+			ingredient.Name != test.Name ||
+			core.ValueOrDefault(ingredient.Description, "") != test.Description {
+			t.Errorf(
+				"ParseLine(%q) = %v %q %q %q, want %v %q %q %q",
+				test.Line,
+				ingredient.Amount, ingredient.UnitType, ingredient.Name, core.ValueOrDefault(ingredient.Description, ""),
+				test.Amount, test.UnitType, test.Name, test.Description,
+			)
+		}
+	}
+}
+
+func TestParseText(t *testing.T) {
+	text := "For the dough:\n" +
+		"500g flour\n" +
+		"\n" +
+		"1 1/2\n" +
+		"- tsp salt\n" +
+		"For the sauce:\n" +
+		"2\n" +
+		"For the topping:\n" +
+		"200 g\n" +
+		"mozzarella, torn\n" +
+		"basil\n"
+	want := []struct {
+		section  string
+		amount   float32
+		unitType string
+		name     string
+	}{
+		{"For the dough", 500, "g", "flour"},
+		// an amount on its own line belongs to the line after it
+		{"For the dough", 1.5, "tsp", "salt"},
+		// but not to a heading
+		{"For the topping", 200, "g", "mozzarella"},
+		{"For the topping", 0, "", "basil"},
+	}
+
+	parsed := ParseText(text)
+	if len(parsed) != len(want) {
+		t.Fatalf("got %d ingredients, want %d: %+v", len(parsed), len(want), parsed)
+	}
+	for i, ingredient := range parsed {
+		if core.ValueOrDefault(ingredient.Section, "") != want[i].section ||
+			ingredient.Amount != want[i].amount ||
+			ingredient.UnitType != want[i].unitType ||
+			ingredient.Name != want[i].name {
+			t.Errorf("ingredient %d = %+v, want %+v", i, ingredient, want[i])
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/my-cooking-codex/api/config"
 	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/db/crud"
 	"github.com/my-cooking-codex/api/routes"
 	"gorm.io/gorm"
 )
@@ -54,6 +55,11 @@ func main() {
 	if err := db.InitDB(appConfig.DB); err != nil {
 		log.Fatalln(err)
 	}
+	if username, err := crud.EnsureAdminExists(); err != nil {
+		log.Fatalln(err)
+	} else if username != nil {
+		log.Println("No admin found, promoted user", *username)
+	}
 	// Create & setup server
 	e := echo.New()
 	e.HTTPErrorHandler = errorHandler
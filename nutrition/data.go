@@ -0,0 +1,139 @@
+package nutrition
+
+import "github.com/my-cooking-codex/api/db"
+
+type food struct {
+	names []string
+	// nutrition in 100 grams
+	per100g db.Nutrition
+	// grams in one, for ingredients counted rather than measured
+	gramsEach float64
+	// grams per millilitre, for foods without a density in the units package
+	gramsPerMl float64
+}
+
+func per100g(calories, protein, fat, carbohydrates, fiber, sodium float64) db.Nutrition {
+	return db.Nutrition{
+		Calories:      calories,
+		Protein:       protein,
+		Fat:           fat,
+		Carbohydrates: carbohydrates,
+		Fiber:         fiber,
+		Sodium:        sodium,
+	}
+}
+
+// Common ingredients from the USDA SR Legacy dataset, uncooked unless named
+// otherwise. More specific names come before the names they contain so
+// "peanut butter" is found before "butter", and "olive oil" before "oil"
+var foods = []food{
+	{[]string{"almond flour", "ground almonds"}, per100g(571, 21.4, 50, 21.4, 10.7, 0), 0, 0.41},
+	{[]string{"bread flour", "strong flour"}, per100g(361, 12, 1.7, 72.8, 2.4, 2), 0, 0},
+	{[]string{"whole wheat flour", "wholemeal flour"}, per100g(340, 13.2, 2.5, 72, 10.7, 2), 0, 0},
+	{[]string{"rye flour"}, per100g(325, 10.9, 2.2, 68.6, 15.1, 1), 0, 0},
+	{[]string{"cornflour", "cornstarch"}, per100g(381, 0.3, 0.1, 91.3, 0.9, 9), 0, 0},
+	{[]string{"cornmeal", "polenta"}, per100g(370, 8.1, 3.6, 79.5, 7.3, 7), 0, 0},
+	{[]string{"flour"}, per100g(364, 10.3, 1, 76.3, 2.7, 2), 0, 0},
+	{[]string{"brown sugar"}, per100g(380, 0.1, 0, 98.1, 0, 28), 0, 0},
+	{[]string{"powdered sugar", "icing sugar", "confectioners sugar"}, per100g(389, 0, 0, 99.8, 0, 2), 0, 0},
+	{[]string{"sugar"}, per100g(387, 0, 0, 100, 0, 1), 0, 0},
+	{[]string{"honey"}, per100g(304, 0.3, 0, 82.4, 0.2, 4), 0, 0},
+	{[]string{"maple syrup"}, per100g(260, 0, 0.1, 67, 0, 12), 0, 0},
+	{[]string{"cocoa"}, per100g(228, 19.6, 13.7, 57.9, 37, 21), 0, 0},
+	{[]string{"chocolate chips"}, per100g(479, 4.2, 30, 63.1, 5.9, 11), 0, 0},
+	{[]string{"chocolate"}, per100g(546, 4.9, 31.3, 61.2, 7, 24), 0, 0},
+	{[]string{"baking powder"}, per100g(53, 0, 0, 27.7, 0.2, 10600), 0, 0},
+	{[]string{"baking soda", "bicarbonate of soda"}, per100g(0, 0, 0, 0, 0, 27360), 0, 0},
+	{[]string{"salt"}, per100g(0, 0, 0, 0, 0, 38758), 0, 0},
+	{[]string{"yeast"}, per100g(325, 40.4, 7.6, 41.2, 26.9, 51), 0, 0},
+	{[]string{"vanilla"}, per100g(288, 0.1, 0.1, 12.7, 0, 9), 0, 0.88},
+	{[]string{"cinnamon"}, per100g(247, 4, 1.2, 80.6, 53.1, 10), 0, 0.56},
+	{[]string{"paprika"}, per100g(282, 14.1, 12.9, 54, 34.9, 68), 0, 0.46},
+	{[]string{"cumin"}, per100g(375, 17.8, 22.3, 44.2, 10.5, 168), 0, 0.4},
+	{[]string{"black pepper"}, per100g(251, 10.4, 3.3, 64, 25.3, 20), 0, 0.46},
+	{[]string{"peanut butter"}, per100g(588, 25.1, 50.4, 19.6, 6, 459), 0, 0},
+	{[]string{"olive oil"}, per100g(884, 0, 100, 0, 0, 2), 0, 0},
+	{[]string{"oil"}, per100g(884, 0, 100, 0, 0, 0), 0, 0},
+	{[]string{"unsalted butter"}, per100g(717, 0.9, 81.1, 0.1, 0, 11), 0, 0},
+	{[]string{"buttermilk"}, per100g(40, 3.3, 0.9, 4.8, 0, 105), 0, 0},
+	{[]string{"butter"}, per100g(717, 0.9, 81.1, 0.1, 0, 643), 0, 0},
+	{[]string{"cream cheese"}, per100g(342, 5.9, 34.2, 4.1, 0, 321), 0, 0},
+	{[]string{"parmesan"}, per100g(431, 38.5, 28.6, 4.1, 0, 1529), 0, 0.42},
+	{[]string{"mozzarella"}, per100g(300, 22.2, 22.4, 2.2, 0, 627), 0, 0.45},
+	{[]string{"feta"}, per100g(264, 14.2, 21.3, 4.1, 0, 1116), 0, 0.45},
+	{[]string{"cheddar", "cheese"}, per100g(403, 24.9, 33.1, 1.3, 0, 621), 0, 0},
+	{[]string{"greek yogurt", "greek yoghurt"}, per100g(97, 9, 5, 3.9, 0, 35), 0, 1.03},
+	{[]string{"yogurt", "yoghurt"}, per100g(61, 3.5, 3.3, 4.7, 0, 46), 0, 0},
+	{[]string{"sour cream"}, per100g(193, 2.4, 19.4, 4.6, 0, 31), 0, 1.0},
+	{[]string{"coconut milk"}, per100g(230, 2.3, 23.8, 5.5, 2.2, 15), 0, 0},
+	{[]string{"almond milk"}, per100g(15, 0.6, 1.1, 0.6, 0.2, 72), 0, 0},
+	{[]string{"cream"}, per100g(340, 2.8, 36.1, 2.7, 0, 27), 0, 0},
+	{[]string{"milk"}, per100g(61, 3.2, 3.3, 4.8, 0, 43), 0, 0},
+	{[]string{"egg white"}, per100g(52, 10.9, 0.2, 0.7, 0, 166), 33, 1.03},
+	{[]string{"egg yolk"}, per100g(322, 15.9, 26.5, 3.6, 0, 48), 17, 1.03},
+	{[]string{"egg"}, per100g(143, 12.6, 9.5, 0.7, 0, 142), 50, 1.03},
+	{[]string{"rolled oats", "oats", "oatmeal"}, per100g(379, 13.2, 6.5, 67.7, 10.1, 6), 0, 0},
+	{[]string{"brown rice"}, per100g(370, 7.9, 2.9, 77.2, 3.5, 7), 0, 0},
+	{[]string{"rice"}, per100g(365, 7.1, 0.7, 80, 1.3, 5), 0, 0},
+	{[]string{"pasta", "spaghetti", "penne", "macaroni", "noodle"}, per100g(371, 13, 1.5, 74.7, 3.2, 6), 0, 0.45},
+	{[]string{"couscous"}, per100g(376, 12.8, 0.6, 77.4, 5, 10), 0, 0},
+	{[]string{"quinoa"}, per100g(368, 14.1, 6.1, 64.2, 7, 5), 0, 0},
+	{[]string{"lentil"}, per100g(352, 24.6, 1.1, 63.4, 10.7, 6), 0, 0},
+	{[]string{"chickpea"}, per100g(139, 7, 2.8, 22.5, 6.4, 246), 0, 0.65},
+	{[]string{"black bean", "kidney bean", "bean"}, per100g(91, 6, 0.3, 16.6, 6.9, 300), 0, 0.7},
+	{[]string{"breadcrumbs"}, per100g(395, 13.4, 5.3, 71.9, 4.5, 732), 0, 0},
+	{[]string{"bread"}, per100g(266, 8.9, 3.3, 49.4, 2.7, 490), 30, 0},
+	{[]string{"raisin"}, per100g(299, 3.1, 0.5, 79.2, 3.7, 11), 0, 0},
+	{[]string{"chicken stock", "beef stock", "vegetable stock", "stock", "broth"}, per100g(7, 1.1, 0.2, 0.4, 0, 371), 0, 0},
+	{[]string{"water", "ice"}, per100g(0, 0, 0, 0, 0, 4), 0, 1.0},
+	{[]string{"wine"}, per100g(85, 0.1, 0, 2.6, 0, 4), 0, 0},
+	{[]string{"vinegar"}, per100g(18, 0, 0, 0, 0, 2), 0, 0},
+	{[]string{"soy sauce"}, per100g(53, 8.1, 0.6, 4.9, 0.8, 5493), 0, 0},
+	{[]string{"lemon juice"}, per100g(22, 0.4, 0.2, 6.9, 0.3, 1), 0, 0},
+	{[]string{"lime juice"}, per100g(25, 0.4, 0.1, 8.4, 0.4, 2), 0, 0},
+	{[]string{"orange juice", "juice"}, per100g(45, 0.7, 0.2, 10.4, 0.2, 1), 0, 0},
+	{[]string{"tomato paste", "tomato puree"}, per100g(82, 4.3, 0.5, 18.9, 4.1, 59), 0, 1.1},
+	{[]string{"chopped tomatoes", "canned tomatoes", "tinned tomatoes"}, per100g(32, 1.6, 0.3, 7.3, 1.9, 143), 0, 1.02},
+	{[]string{"tomato"}, per100g(18, 0.9, 0.2, 3.9, 1.2, 5), 123, 0.7},
+	{[]string{"sweet potato"}, per100g(86, 1.6, 0.1, 20.1, 3, 55), 130, 0},
+	{[]string{"potato"}, per100g(77, 2, 0.1, 17.5, 2.2, 6), 213, 0},
+	{[]string{"onion", "shallot"}, per100g(40, 1.1, 0.1, 9.3, 1.7, 4), 110, 0.67},
+	{[]string{"garlic"}, per100g(149, 6.4, 0.5, 33.1, 2.1, 17), 3, 0.57},
+	{[]string{"carrot"}, per100g(41, 0.9, 0.2, 9.6, 2.8, 69), 61, 0.54},
+	{[]string{"celery"}, per100g(14, 0.7, 0.2, 3, 1.6, 80), 40, 0.43},
+	{[]string{"bell pepper"}, per100g(31, 1, 0.3, 6, 2.1, 4), 119, 0.63},
+	{[]string{"mushroom"}, per100g(22, 3.1, 0.3, 3.3, 1, 5), 18, 0.3},
+	{[]string{"spinach"}, per100g(23, 2.9, 0.4, 3.6, 2.2, 79), 0, 0.13},
+	{[]string{"broccoli"}, per100g(34, 2.8, 0.4, 6.6, 2.6, 33), 0, 0.38},
+	{[]string{"courgette", "zucchini"}, per100g(17, 1.2, 0.3, 3.1, 1, 8), 196, 0.53},
+	{[]string{"cucumber"}, per100g(15, 0.7, 0.1, 3.6, 0.5, 2), 301, 0.44},
+	{[]string{"pea"}, per100g(81, 5.4, 0.4, 14.5, 5.7, 5), 0, 0.61},
+	{[]string{"sweetcorn", "corn"}, per100g(86, 3.3, 1.4, 19, 2.7, 15), 0, 0.61},
+	{[]string{"parsley"}, per100g(36, 3, 0.8, 6.3, 3.3, 56), 0, 0.25},
+	{[]string{"coriander", "cilantro"}, per100g(23, 2.1, 0.5, 3.7, 2.8, 46), 0, 0.07},
+	{[]string{"basil"}, per100g(23, 3.2, 0.6, 2.7, 1.6, 4), 0, 0.09},
+	{[]string{"avocado"}, per100g(160, 2, 14.7, 8.5, 6.7, 7), 150, 0},
+	{[]string{"apple"}, per100g(52, 0.3, 0.2, 13.8, 2.4, 1), 182, 0.53},
+	{[]string{"banana"}, per100g(89, 1.1, 0.3, 22.8, 2.6, 1), 118, 0.95},
+	{[]string{"lemon"}, per100g(29, 1.1, 0.3, 9.3, 2.8, 2), 58, 0},
+	{[]string{"lime"}, per100g(30, 0.7, 0.2, 10.5, 2.8, 2), 67, 0},
+	{[]string{"orange"}, per100g(47, 0.9, 0.1, 11.8, 2.4, 0), 131, 0},
+	{[]string{"strawberry", "strawberries"}, per100g(32, 0.7, 0.3, 7.7, 2, 1), 12, 0.6},
+	{[]string{"blueberry", "blueberries"}, per100g(57, 0.7, 0.3, 14.5, 2.4, 1), 0, 0.6},
+	{[]string{"almond"}, per100g(579, 21.2, 49.9, 21.6, 12.5, 1), 1.2, 0.6},
+	{[]string{"walnut"}, per100g(654, 15.2, 65.2, 13.7, 6.7, 2), 4, 0.42},
+	{[]string{"peanut"}, per100g(567, 25.8, 49.2, 16.1, 8.5, 18), 0, 0.6},
+	{[]string{"chicken breast"}, per100g(120, 22.5, 2.6, 0, 0, 45), 174, 0},
+	{[]string{"chicken thigh"}, per100g(121, 19.7, 4.1, 0, 0, 95), 110, 0},
+	{[]string{"chicken"}, per100g(119, 21.4, 3.1, 0, 0, 77), 0, 0},
+	{[]string{"ground beef", "minced beef", "beef mince"}, per100g(254, 17.2, 20, 0, 0, 66), 0, 0},
+	{[]string{"beef", "steak"}, per100g(198, 19.4, 12.7, 0, 0, 60), 0, 0},
+	{[]string{"bacon"}, per100g(417, 13, 39.7, 1.4, 0, 833), 25, 0},
+	{[]string{"sausage"}, per100g(301, 12.7, 27, 1, 0, 750), 75, 0},
+	{[]string{"ham"}, per100g(145, 21, 5.5, 1.5, 0, 1203), 28, 0},
+	{[]string{"pork"}, per100g(143, 21.2, 5.7, 0, 0, 52), 0, 0},
+	{[]string{"salmon"}, per100g(208, 20.4, 13.4, 0, 0, 59), 0, 0},
+	{[]string{"tuna"}, per100g(116, 25.5, 0.8, 0, 0, 247), 0, 0},
+	{[]string{"prawn", "shrimp"}, per100g(85, 20.1, 0.5, 0, 0, 119), 0, 0},
+	{[]string{"tofu"}, per100g(76, 8.1, 4.8, 1.9, 0.3, 7), 0, 0},
+}
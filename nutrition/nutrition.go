@@ -0,0 +1,147 @@
+package nutrition
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/units"
+)
+
+// Grams in a pinch or dash, which are only used for small amounts
+const pinchGrams = 0.36
+
+var nonLetterRegex = regexp.MustCompile(`[^\p{L}]+`)
+
+func findFood(ingredientName string) (food, bool) {
+	name := " " + strings.TrimSpace(nonLetterRegex.ReplaceAllString(strings.ToLower(ingredientName), " ")) + " "
+	for _, food := range foods {
+		for _, foodName := range food.names {
+			if strings.Contains(name, " "+foodName+" ") ||
+				strings.Contains(name, " "+foodName+"s ") ||
+				strings.Contains(name, " "+foodName+"es ") {
+				return food, true
+			}
+		}
+	}
+	return food{}, false
+}
+
+// Weigh an ingredient in grams, by its density when measured by volume
+// and by what one weighs when counted. False if it can't be weighed
+func ingredientGrams(ingredient db.RecipeIngredient, food food) (float64, bool) {
+	unit, ok := units.Lookup(ingredient.UnitType)
+	if !ok {
+		return 0, false
+	}
+	amount := float64(ingredient.Amount)
+	switch unit.Kind {
+	case units.KindMass:
+		return amount * unit.Size, true
+	case units.KindVolume:
+		density, ok := units.Density(ingredient.Name)
+		if !ok {
+			density = food.gramsPerMl
+		}
+		return amount * unit.Size * density, density != 0
+	}
+	switch unit.Name {
+	case "pinch", "dash":
+		return amount * pinchGrams, true
+	case "", "whole", "each", "piece", "clove", "slice":
+		return amount * food.gramsEach, food.gramsEach != 0
+	}
+	return 0, false
+}
+
+type estimate struct {
+	nutrition db.Nutrition
+	// whether every ingredient could be estimated
+	complete bool
+}
+
+// A key for the recipe expanded from the ingredient at path, as the same
+// recipe can be used at different scales
+func pathKey(path []uint) string {
+	return fmt.Sprint(path)
+}
+
+// Add up the nutrition of ingredients, returning the indexes of the ones that
+// couldn't be estimated. Ingredients without an amount, such as "salt to
+// taste", are left out without being flagged. Path leads to the recipe the
+// ingredients are from, empty for the outer recipe
+func estimateIngredients(ingredients []db.RecipeIngredient, path []uint, subRecipes map[string]estimate) (db.Nutrition, []uint) {
+	var total db.Nutrition
+	unmatched := []uint{}
+	for i, ingredient := range ingredients {
+		if ingredient.Nutrition != nil {
+			total = total.Add(*ingredient.Nutrition)
+			continue
+		}
+		if ingredient.RecipeID != nil {
+			subRecipe, ok := subRecipes[pathKey(append(append([]uint{}, path...), uint(i)))]
+			if ok {
+				total = total.Add(subRecipe.nutrition)
+			}
+			if !ok || !subRecipe.complete {
+				unmatched = append(unmatched, uint(i))
+			}
+			continue
+		}
+		if ingredient.Amount == 0 {
+			continue
+		}
+		food, ok := findFood(ingredient.Name)
+		if !ok {
+			unmatched = append(unmatched, uint(i))
+			continue
+		}
+		grams, ok := ingredientGrams(ingredient, food)
+		if !ok {
+			unmatched = append(unmatched, uint(i))
+			continue
+		}
+		total = total.Add(food.per100g.Scale(grams / 100))
+	}
+	return total, unmatched
+}
+
+func roundNutrition(nutrition db.Nutrition) db.Nutrition {
+	return db.Nutrition{
+		Calories:      math.Round(nutrition.Calories),
+		Protein:       math.Round(nutrition.Protein*10) / 10,
+		Fat:           math.Round(nutrition.Fat*10) / 10,
+		Carbohydrates: math.Round(nutrition.Carbohydrates*10) / 10,
+		Fiber:         math.Round(nutrition.Fiber*10) / 10,
+		Sodium:        math.Round(nutrition.Sodium),
+	}
+}
+
+// Estimate a recipe's nutrition from its ingredients, per serving when it has
+// yields. Recipes used as ingredients are estimated from their SubRecipes,
+// and are flagged as unmatched when they weren't expanded
+func Estimate(recipe db.ReadRecipe) db.RecipeNutrition {
+	// recipes are expanded after the ones they use, so those are estimated first
+	subRecipes := map[string]estimate{}
+	for _, subRecipe := range recipe.SubRecipes {
+		total, unmatched := estimateIngredients(subRecipe.Ingredients, subRecipe.Path, subRecipes)
+		subRecipes[pathKey(subRecipe.Path)] = estimate{total, len(unmatched) == 0}
+	}
+
+	var ingredients []db.RecipeIngredient
+	if recipe.Ingredients != nil {
+		ingredients = *recipe.Ingredients
+	}
+	total, unmatched := estimateIngredients(ingredients, nil, subRecipes)
+	nutrition := db.RecipeNutrition{
+		Total:     roundNutrition(total),
+		Unmatched: unmatched,
+	}
+	if recipe.Info.Yields != nil && recipe.Info.Yields.Data().Value != 0 {
+		perServing := roundNutrition(total.Scale(1 / float64(recipe.Info.Yields.Data().Value)))
+		nutrition.PerServing = &perServing
+	}
+	return nutrition
+}
@@ -0,0 +1,57 @@
+package nutrition
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/my-cooking-codex/api/db"
+)
+
+func TestEstimate(t *testing.T) {
+	flour := []db.RecipeIngredient{{Name: "flour", Amount: 1, UnitType: "g", Nutrition: &db.Nutrition{Calories: 100}}}
+	recipe := db.ReadRecipe{Ingredients: &[]db.RecipeIngredient{
+		flour[0],
+		{Name: "salt", UnitType: "pinch"},
+	}}
+	nutrition := Estimate(recipe)
+	if nutrition.Total.Calories != 100 || len(nutrition.Unmatched) != 0 {
+		t.Errorf("nutrition = %+v, want 100 calories with nothing unmatched", nutrition)
+	}
+}
+
+func TestEstimateSharedSubRecipe(t *testing.T) {
+	b, c := uuid.New(), uuid.New()
+	calories := func(value float64) []db.RecipeIngredient {
+		return []db.RecipeIngredient{{Name: "sugar", Amount: 1, Nutrition: &db.Nutrition{Calories: value}}}
+	}
+	// A uses B twice over and C, which uses B once, expanded as they are for a recipe
+	recipe := db.ReadRecipe{
+		Ingredients: &[]db.RecipeIngredient{
+			{Name: "b", Amount: 2, RecipeID: &b},
+			{Name: "c", Amount: 1, RecipeID: &c},
+		},
+		SubRecipes: []db.SubRecipe{
+			{RecipeID: b, Path: []uint{0}, Scale: 2, Ingredients: calories(200)},
+			{RecipeID: b, Path: []uint{1, 1}, Scale: 1, Ingredients: calories(100)},
+			{RecipeID: c, Path: []uint{1}, Scale: 1, Ingredients: append(calories(10), db.RecipeIngredient{
+				Name: "b", Amount: 1, RecipeID: &b,
+			})},
+		},
+	}
+	nutrition := Estimate(recipe)
+	// B at twice its size, then C with B at its own size
+	if want := 200.0 + 10 + 100; nutrition.Total.Calories != want {
+		t.Errorf("calories = %v, want %v", nutrition.Total.Calories, want)
+	}
+	if len(nutrition.Unmatched) != 0 {
+		t.Errorf("unmatched = %v, want none", nutrition.Unmatched)
+	}
+}
+
+func TestEstimateUnexpandedSubRecipe(t *testing.T) {
+	b := uuid.New()
+	recipe := db.ReadRecipe{Ingredients: &[]db.RecipeIngredient{{Name: "b", Amount: 1, RecipeID: &b}}}
+	if nutrition := Estimate(recipe); len(nutrition.Unmatched) != 1 || nutrition.Unmatched[0] != 0 {
+		t.Errorf("unmatched = %v, want the recipe that wasn't expanded", nutrition.Unmatched)
+	}
+}
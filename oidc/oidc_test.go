@@ -0,0 +1,304 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID     = "client"
+	testClientSecret = "secret"
+	testCode         = "code"
+)
+
+// An identity provider that hands out id tokens for testCode,
+// when the PKCE verifier matches the challenge it was given
+type mockIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	keyID  string
+
+	mu          sync.Mutex
+	challenge   string
+	claims      jwt.MapClaims
+	signingKey  *rsa.PrivateKey
+	tokenKeyID  string
+	jwksFetches int
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := &mockIssuer{key: key, keyID: "key-1"}
+	issuer.signingKey, issuer.tokenKeyID = key, issuer.keyID
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(providerMetadata{
+			Issuer:                issuer.server.URL,
+			AuthorizationEndpoint: issuer.server.URL + "/authorize",
+			TokenEndpoint:         issuer.server.URL + "/token",
+			JWKSURI:               issuer.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		issuer.mu.Lock()
+		issuer.jwksFetches++
+		issuer.mu.Unlock()
+		json.NewEncoder(w).Encode(jsonWebKeySet{Keys: []jsonWebKey{{
+			Kid: issuer.keyID,
+			Kty: "RSA",
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", issuer.handleToken)
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func s256(codeVerifier string) string {
+	hash := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+func (i *mockIssuer) handleToken(w http.ResponseWriter, r *http.Request) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	clientID, clientSecret, _ := r.BasicAuth()
+	if clientID != testClientID || clientSecret != testClientSecret {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(tokenResponse{Error: "invalid_client"})
+		return
+	}
+	if r.PostFormValue("grant_type") != "authorization_code" ||
+		r.PostFormValue("code") != testCode ||
+		s256(r.PostFormValue("code_verifier")) != i.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(tokenResponse{Error: "invalid_grant"})
+		return
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, i.claims)
+	token.Header["kid"] = i.tokenKeyID
+	idToken, err := token.SignedString(i.signingKey)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(tokenResponse{IDToken: idToken})
+}
+
+// Start a login like a user would, returning the provider, verifier and nonce used
+func (i *mockIssuer) startLogin(t *testing.T) (*Provider, string, string) {
+	t.Helper()
+	provider := NewProvider(i.server.URL+"/", testClientID, testClientSecret, "https://app.example/callback", []string{"openid"})
+	codeVerifier, _ := NewRandomString()
+	nonce, _ := NewRandomString()
+	authURL, err := provider.AuthorizationURL(context.Background(), "state", nonce, codeVerifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i.mu.Lock()
+	i.challenge = parsed.Query().Get("code_challenge")
+	i.claims = jwt.MapClaims{
+		"iss":   i.server.URL,
+		"aud":   testClientID,
+		"sub":   "subject",
+		"nonce": nonce,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"iat":   time.Now().Unix(),
+	}
+	i.mu.Unlock()
+	return provider, codeVerifier, nonce
+}
+
+func TestAuthorizationURL(t *testing.T) {
+	issuer := newMockIssuer(t)
+	provider := NewProvider(issuer.server.URL, testClientID, testClientSecret, "https://app.example/callback", []string{"openid", "profile"})
+
+	authURL, err := provider.AuthorizationURL(context.Background(), "state", "nonce", "verifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Path != "/authorize" {
+		t.Errorf("path = %q, want /authorize", parsed.Path)
+	}
+	expected := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          "https://app.example/callback",
+		"scope":                 "openid profile",
+		"state":                 "state",
+		"nonce":                 "nonce",
+		"code_challenge":        s256("verifier"),
+		"code_challenge_method": "S256",
+	}
+	for name, value := range expected {
+		if got := parsed.Query().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(providerMetadata{Issuer: "https://evil.example"})
+	}))
+	defer server.Close()
+	provider := NewProvider(server.URL, testClientID, testClientSecret, "", nil)
+	if _, err := provider.AuthorizationURL(context.Background(), "state", "nonce", "verifier"); err == nil {
+		t.Error("expected an error when the issuer doesn't match")
+	}
+}
+
+func TestExchange(t *testing.T) {
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		// change the login before the code is exchanged
+		modify       func(issuer *mockIssuer, verifier *string, nonce *string)
+		wantErr      bool
+		wantIDTokErr bool
+	}{
+		{
+			name:   "valid",
+			modify: func(issuer *mockIssuer, verifier *string, nonce *string) {},
+		},
+		{
+			name:    "wrong code verifier",
+			modify:  func(issuer *mockIssuer, verifier *string, nonce *string) { *verifier = "wrong" },
+			wantErr: true,
+		},
+		{
+			name:         "wrong nonce",
+			modify:       func(issuer *mockIssuer, verifier *string, nonce *string) { *nonce = "wrong" },
+			wantErr:      true,
+			wantIDTokErr: true,
+		},
+		{
+			name: "wrong audience",
+			modify: func(issuer *mockIssuer, verifier *string, nonce *string) {
+				issuer.claims["aud"] = "someone-else"
+			},
+			wantErr:      true,
+			wantIDTokErr: true,
+		},
+		{
+			name: "wrong issuer",
+			modify: func(issuer *mockIssuer, verifier *string, nonce *string) {
+				issuer.claims["iss"] = "https://evil.example"
+			},
+			wantErr:      true,
+			wantIDTokErr: true,
+		},
+		{
+			name: "expired",
+			modify: func(issuer *mockIssuer, verifier *string, nonce *string) {
+				issuer.claims["exp"] = time.Now().Add(-time.Hour).Unix()
+			},
+			wantErr:      true,
+			wantIDTokErr: true,
+		},
+		{
+			name: "no subject",
+			modify: func(issuer *mockIssuer, verifier *string, nonce *string) {
+				delete(issuer.claims, "sub")
+			},
+			wantErr:      true,
+			wantIDTokErr: true,
+		},
+		{
+			name: "signed by another key",
+			modify: func(issuer *mockIssuer, verifier *string, nonce *string) {
+				issuer.signingKey = otherKey
+			},
+			wantErr:      true,
+			wantIDTokErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			issuer := newMockIssuer(t)
+			provider, verifier, nonce := issuer.startLogin(t)
+			issuer.mu.Lock()
+			test.modify(issuer, &verifier, &nonce)
+			issuer.mu.Unlock()
+
+			claims, err := provider.Exchange(context.Background(), testCode, verifier, nonce)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				if test.wantIDTokErr && !errors.Is(err, ErrInvalidIDToken) {
+					t.Errorf("error = %v, want ErrInvalidIDToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.Subject != "subject" {
+				t.Errorf("subject = %q, want subject", claims.Subject)
+			}
+		})
+	}
+}
+
+func TestUnknownKeyRefetchIsLimited(t *testing.T) {
+	issuer := newMockIssuer(t)
+	provider, verifier, nonce := issuer.startLogin(t)
+	if _, err := provider.Exchange(context.Background(), testCode, verifier, nonce); err != nil {
+		t.Fatal(err)
+	}
+
+	issuer.mu.Lock()
+	issuer.tokenKeyID = "made-up"
+	issuer.mu.Unlock()
+	for i := 0; i < 3; i++ {
+		if _, err := provider.Exchange(context.Background(), testCode, verifier, nonce); err == nil {
+			t.Fatal("expected an error for an unknown key")
+		}
+	}
+	if issuer.jwksFetches != 1 {
+		t.Errorf("key set fetched %d times, want 1", issuer.jwksFetches)
+	}
+
+	// once the interval has passed the key set can be fetched again
+	provider.keysFetchedAt = time.Now().Add(-jwksRefreshInterval)
+	if _, err := provider.Exchange(context.Background(), testCode, verifier, nonce); err == nil {
+		t.Fatal("expected an error for an unknown key")
+	}
+	if issuer.jwksFetches != 2 {
+		t.Errorf("key set fetched %d times, want 2", issuer.jwksFetches)
+	}
+}
@@ -0,0 +1,107 @@
+package policy
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/db/crud"
+)
+
+type Resource string
+
+const (
+	Recipe         Resource = "recipe"
+	PantryLocation Resource = "pantry location"
+	PantryItem     Resource = "pantry item"
+	Household      Resource = "household"
+)
+
+type Action string
+
+const (
+	Read   Action = "read"
+	Write  Action = "write"
+	Delete Action = "delete"
+	// change who has access, e.g. moving into a household or managing members
+	Manage Action = "manage"
+)
+
+type Decision int
+
+const (
+	// the resource doesn't exist or the user can't see it
+	NotFound Decision = iota
+	Forbidden
+	Allowed
+)
+
+var roleRank = map[db.HouseholdRole]int{
+	db.HouseholdViewer: 1,
+	db.HouseholdEditor: 2,
+	db.HouseholdOwner:  3,
+}
+
+// The lowest role needed for each action, actions not listed are never allowed
+var rules = map[Resource]map[Action]db.HouseholdRole{
+	Recipe: {
+		Read:   db.HouseholdViewer,
+		Write:  db.HouseholdEditor,
+		Delete: db.HouseholdOwner,
+		Manage: db.HouseholdOwner,
+	},
+	PantryLocation: {
+		Read:   db.HouseholdViewer,
+		Write:  db.HouseholdEditor,
+		Delete: db.HouseholdOwner,
+		Manage: db.HouseholdOwner,
+	},
+	PantryItem: {
+		Read:   db.HouseholdViewer,
+		Write:  db.HouseholdEditor,
+		Delete: db.HouseholdEditor,
+	},
+	Household: {
+		Read: db.HouseholdViewer,
+		// adding recipes or pantry locations to it
+		Write:  db.HouseholdEditor,
+		Delete: db.HouseholdOwner,
+		Manage: db.HouseholdOwner,
+	},
+}
+
+// Check whether a role allows an action on a kind of resource
+func Allows(resource Resource, role db.HouseholdRole, action Action) bool {
+	required, ok := rules[resource][action]
+	return ok && roleRank[role] != 0 && roleRank[role] >= roleRank[required]
+}
+
+// Get the role a user has for a resource, empty when it doesn't exist or can't be seen
+func RoleFor(userID uuid.UUID, resource Resource, resourceID uuid.UUID) (db.HouseholdRole, error) {
+	switch resource {
+	case Recipe:
+		return crud.GetRecipeRole(userID, resourceID)
+	case PantryLocation:
+		return crud.GetPantryLocationRole(userID, resourceID)
+	case PantryItem:
+		return crud.GetPantryItemRole(userID, resourceID)
+	case Household:
+		return crud.GetHouseholdRole(userID, resourceID)
+	}
+	return "", fmt.Errorf("unknown resource '%s'", resource)
+}
+
+// Decide whether a user may perform an action on a resource,
+// resources they can't read are reported as not found so their existence isn't leaked
+func Decide(userID uuid.UUID, resource Resource, resourceID uuid.UUID, action Action) (Decision, error) {
+	role, err := RoleFor(userID, resource, resourceID)
+	if err != nil {
+		return NotFound, err
+	}
+	if !Allows(resource, role, Read) {
+		return NotFound, nil
+	} else if !Allows(resource, role, action) {
+		return Forbidden, nil
+	}
+	return Allowed, nil
+}
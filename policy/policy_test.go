@@ -0,0 +1,159 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/db/crud"
+	"github.com/my-cooking-codex/api/db/dbtest"
+	"github.com/my-cooking-codex/api/db/types"
+)
+
+var (
+	allResources = []Resource{Recipe, PantryLocation, PantryItem, Household}
+	allActions   = []Action{Read, Write, Delete, Manage}
+)
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		resource Resource
+		role     db.HouseholdRole
+		allowed  []Action
+	}{
+		{Recipe, db.HouseholdOwner, []Action{Read, Write, Delete, Manage}},
+		{Recipe, db.HouseholdEditor, []Action{Read, Write}},
+		{Recipe, db.HouseholdViewer, []Action{Read}},
+		{Recipe, "", nil},
+		{Recipe, "admin", nil},
+		{PantryLocation, db.HouseholdOwner, []Action{Read, Write, Delete, Manage}},
+		{PantryLocation, db.HouseholdEditor, []Action{Read, Write}},
+		{PantryLocation, db.HouseholdViewer, []Action{Read}},
+		{PantryLocation, "", nil},
+		// items move with their location, so they are never managed directly
+		{PantryItem, db.HouseholdOwner, []Action{Read, Write, Delete}},
+		{PantryItem, db.HouseholdEditor, []Action{Read, Write, Delete}},
+		{PantryItem, db.HouseholdViewer, []Action{Read}},
+		{PantryItem, "", nil},
+		{Household, db.HouseholdOwner, []Action{Read, Write, Delete, Manage}},
+		{Household, db.HouseholdEditor, []Action{Read, Write}},
+		{Household, db.HouseholdViewer, []Action{Read}},
+		{Household, "", nil},
+		{"unknown", db.HouseholdOwner, nil},
+	}
+	for _, test := range tests {
+		for _, action := range allActions {
+			want := false
+			for _, allowed := range test.allowed {
+				want = want || allowed == action
+			}
+			if got := Allows(test.resource, test.role, action); got != want {
+				t.Errorf("Allows(%s, %q, %s) = %v, want %v", test.resource, test.role, action, got, want)
+			}
+		}
+	}
+}
+
+func TestDecide(t *testing.T) {
+	dbtest.Setup(t)
+	ownerID := dbtest.CreateUser(t, "owner").ID
+	editorID := dbtest.CreateUser(t, "editor").ID
+	viewerID := dbtest.CreateUser(t, "viewer").ID
+	outsiderID := dbtest.CreateUser(t, "outsider").ID
+
+	household, err := crud.CreateHousehold(types.CreateHousehold{Name: "Home"}, ownerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := crud.AddHouseholdMember(household.ID, editorID, db.HouseholdEditor); err != nil {
+		t.Fatal(err)
+	}
+	if err := crud.AddHouseholdMember(household.ID, viewerID, db.HouseholdViewer); err != nil {
+		t.Fatal(err)
+	}
+
+	sharedRecipe, err := crud.CreateRecipe(db.CreateRecipe{Title: "Shared", HouseholdID: &household.ID}, ownerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateRecipe, err := crud.CreateRecipe(db.CreateRecipe{Title: "Private"}, ownerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	location, err := crud.CreatePantryLocation(types.CreatePantryLocation{Name: "Fridge", HouseholdID: &household.ID}, ownerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item, err := crud.CreatePantryItem(types.CreatePantryItem{Name: "Milk"}, location.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resourceIDs := map[Resource]uuid.UUID{
+		Recipe:         sharedRecipe.ID,
+		PantryLocation: location.ID,
+		PantryItem:     item.ID,
+		Household:      household.ID,
+	}
+
+	// the decision for each action, in the order of allActions
+	owner := []Decision{Allowed, Allowed, Allowed, Allowed}
+	editor := []Decision{Allowed, Allowed, Forbidden, Forbidden}
+	viewer := []Decision{Allowed, Forbidden, Forbidden, Forbidden}
+	hidden := []Decision{NotFound, NotFound, NotFound, NotFound}
+	tests := []struct {
+		user     string
+		userID   uuid.UUID
+		resource Resource
+		want     []Decision
+	}{
+		{"owner", ownerID, Recipe, owner},
+		{"owner", ownerID, PantryLocation, owner},
+		{"owner", ownerID, PantryItem, []Decision{Allowed, Allowed, Allowed, Forbidden}},
+		{"owner", ownerID, Household, owner},
+		{"editor", editorID, Recipe, editor},
+		{"editor", editorID, PantryLocation, editor},
+		{"editor", editorID, PantryItem, []Decision{Allowed, Allowed, Allowed, Forbidden}},
+		{"editor", editorID, Household, editor},
+		{"viewer", viewerID, Recipe, viewer},
+		{"viewer", viewerID, PantryLocation, viewer},
+		{"viewer", viewerID, PantryItem, viewer},
+		{"viewer", viewerID, Household, viewer},
+		{"outsider", outsiderID, Recipe, hidden},
+		{"outsider", outsiderID, PantryLocation, hidden},
+		{"outsider", outsiderID, PantryItem, hidden},
+		{"outsider", outsiderID, Household, hidden},
+	}
+	for _, test := range tests {
+		for i, action := range allActions {
+			got, err := Decide(test.userID, test.resource, resourceIDs[test.resource], action)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want[i] {
+				t.Errorf("%s %s %s = %d, want %d", test.user, action, test.resource, got, test.want[i])
+			}
+		}
+	}
+
+	// recipes outside of a household can only be seen by their owner
+	for _, userID := range []uuid.UUID{editorID, viewerID, outsiderID} {
+		for _, action := range allActions {
+			if got, err := Decide(userID, Recipe, privateRecipe.ID, action); err != nil {
+				t.Fatal(err)
+			} else if got != NotFound {
+				t.Errorf("private recipe %s = %d, want NotFound", action, got)
+			}
+		}
+	}
+	// resources that don't exist
+	for _, resource := range allResources {
+		if got, err := Decide(ownerID, resource, uuid.New(), Read); err != nil {
+			t.Fatal(err)
+		} else if got != NotFound {
+			t.Errorf("missing %s = %d, want NotFound", resource, got)
+		}
+	}
+	if _, err := Decide(ownerID, "unknown", uuid.New(), Read); err == nil {
+		t.Error("expected an error for an unknown resource")
+	}
+}
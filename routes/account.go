@@ -0,0 +1,245 @@
+package routes
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/bytes"
+	"github.com/my-cooking-codex/api/config"
+	"github.com/my-cooking-codex/api/core"
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/db/crud"
+	"github.com/my-cooking-codex/api/db/types"
+	"github.com/my-cooking-codex/api/importers"
+)
+
+func writeArchiveJSON(archive *zip.Writer, name string, value any) error {
+	w, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// Add a recipe's image to an export, returning whether it was found
+func writeArchiveImage(archive *zip.Writer, appConfig config.AppConfig, imageID string) (bool, error) {
+	image, err := os.Open(path.Join(appConfig.Data.RecipeOriginalsPath(), imageID+".jpg"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	defer image.Close()
+	// images are already compressed
+	w, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     "images/" + imageID + ".jpg",
+		Method:   zip.Store,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return false, err
+	}
+	_, err = io.Copy(w, image)
+	return err == nil, err
+}
+
+func writeAccountExport(
+	w io.Writer,
+	appConfig config.AppConfig,
+	user db.User,
+	recipes []db.ReadRecipe,
+	locations []types.ExportPantryLocation,
+) error {
+	archive := zip.NewWriter(w)
+	manifest := types.ExportManifest{
+		SchemaVersion:   types.ExportSchemaVersion,
+		ExportedAt:      time.Now().UTC(),
+		Username:        user.Username,
+		Recipes:         len(recipes),
+		PantryLocations: len(locations),
+	}
+	labels := make([]string, 0)
+	seenLabels := map[string]bool{}
+	addLabels := func(names []string) {
+		for _, name := range names {
+			if !seenLabels[name] {
+				seenLabels[name] = true
+				labels = append(labels, name)
+			}
+		}
+	}
+
+	for _, recipe := range recipes {
+		if err := writeArchiveJSON(archive, "recipes/"+recipe.ID.String()+".json", recipe); err != nil {
+			return err
+		}
+		addLabels(recipe.Labels)
+		if recipe.ImageID != nil {
+			if found, err := writeArchiveImage(archive, appConfig, recipe.ImageID.String()); err != nil {
+				return err
+			} else if found {
+				manifest.Images++
+			}
+		}
+	}
+	for _, location := range locations {
+		if err := writeArchiveJSON(archive, "pantry/"+location.ID.String()+".json", location); err != nil {
+			return err
+		}
+		for _, item := range location.Items {
+			addLabels(item.Labels)
+		}
+		manifest.PantryItems += len(location.Items)
+	}
+	manifest.Labels = len(labels)
+	if err := writeArchiveJSON(archive, "labels.json", labels); err != nil {
+		return err
+	}
+	if err := writeArchiveJSON(archive, "manifest.json", manifest); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+func getUserMeExport(ctx echo.Context) error {
+	appConfig := ctx.Get("AppConfig").(config.AppConfig)
+	user := getUser(ctx)
+
+	recipes, err := crud.GetRecipesByOwnerID(user.ID)
+	if err != nil {
+		return err
+	}
+	locations, err := crud.GetPantryLocationsWithItemsByOwnerID(user.ID)
+	if err != nil {
+		return err
+	}
+
+	response := ctx.Response()
+	response.Header().Set(echo.HeaderContentType, "application/zip")
+	response.Header().Set(
+		echo.HeaderContentDisposition,
+		fmt.Sprintf(`attachment; filename="my-cooking-codex-%s-%s.zip"`, user.Username, time.Now().UTC().Format("2006-01-02")),
+	)
+	response.WriteHeader(http.StatusOK)
+	if err := writeAccountExport(response, appConfig, user, recipes, locations); err != nil {
+		// the response has already started, so this can only be logged
+		ctx.Logger().Errorf("failed to export account '%s': %s", user.ID, err)
+	}
+	return nil
+}
+
+// Check everything in an account export is valid, as it could have been edited
+func validateAccountArchive(ctx echo.Context, archive importers.AccountArchive) error {
+	for _, recipe := range archive.Recipes {
+		createRecipe := recipe.IntoCreateRecipe()
+		if err := ctx.Validate(&createRecipe); err != nil {
+			return fmt.Errorf("recipe '%s' is not valid: %s", recipe.ID, errorMessage(err))
+		}
+	}
+	for _, location := range archive.PantryLocations {
+		if err := ctx.Validate(&types.CreatePantryLocation{Name: location.Name}); err != nil {
+			return fmt.Errorf("pantry location '%s' is not valid: %s", location.ID, errorMessage(err))
+		}
+		for _, item := range location.Items {
+			if err := ctx.Validate(&types.CreatePantryItem{Name: item.Name, Labels: item.Labels}); err != nil {
+				return fmt.Errorf("pantry item '%s' is not valid: %s", item.ID, errorMessage(err))
+			}
+		}
+	}
+	for _, label := range archive.Labels {
+		if label == "" || len([]rune(label)) > 60 {
+			return fmt.Errorf("label '%s' is not valid", label)
+		}
+	}
+	return nil
+}
+
+func postUserMeImport(ctx echo.Context) error {
+	appConfig := ctx.Get("AppConfig").(config.AppConfig)
+	authenticatedUser := getAuthenticatedUser(ctx)
+
+	var formData types.ImportAccount
+	if err := core.BindAndValidate(ctx, &formData); err != nil {
+		return err
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, "file is required")
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+
+	maxSize, err := bytes.Parse(appConfig.Import.MaxArchiveSize)
+	if err != nil {
+		return err
+	}
+	archive, err := importers.ReadAccountArchive(content, maxSize*archiveExtractRatio)
+	if err != nil {
+		if errors.Is(err, importers.ErrArchiveTooLarge) {
+			return ctx.JSON(http.StatusRequestEntityTooLarge, "archive content is too large")
+		} else if errors.Is(err, importers.ErrUnsupportedSchema) {
+			return ctx.JSON(http.StatusBadRequest, "export is from an unsupported version")
+		} else if errors.Is(err, importers.ErrInvalidArchive) {
+			return ctx.JSON(http.StatusBadRequest, "file is not a valid account export")
+		}
+		return err
+	}
+	if err := validateAccountArchive(ctx, archive); err != nil {
+		return ctx.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	report, err := crud.ImportAccount(authenticatedUser.UserID, crud.AccountImport{
+		Recipes:         archive.Recipes,
+		PantryLocations: archive.PantryLocations,
+		Labels:          archive.Labels,
+	}, formData.Conflicts == "keep")
+	if err != nil {
+		return recipeErrorResponse(ctx, err)
+	}
+
+	// images are stored once their recipe exists, a failure only loses the image
+	for _, recipe := range archive.Recipes {
+		recipeID, created := report.IDs[recipe.ID]
+		if !created || recipe.ImageID == nil {
+			continue
+		}
+		content, ok := archive.Images[*recipe.ImageID]
+		if !ok {
+			report.Images.Skipped++
+			continue
+		}
+		if imageID, err := saveRecipeImage(appConfig, content); err != nil {
+			report.Images.Skipped++
+		} else if err := crud.UpdateRecipeImage(recipeID, &imageID, authenticatedUser.UserID); err != nil {
+			removeRecipeImage(appConfig, imageID)
+			return err
+		} else {
+			report.Images.Created++
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,194 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/my-cooking-codex/api/config"
+	"github.com/my-cooking-codex/api/core"
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/db/crud"
+)
+
+func getAdminUsers(ctx echo.Context) error {
+	var paginationParams core.PaginationParams
+	if err := core.BindAndValidate(ctx, &paginationParams); err != nil {
+		return err
+	}
+
+	// convert human page number into database offset
+	rowOffset := (paginationParams.Page - 1) * paginationParams.PerPage
+
+	if users, err := crud.GetUsers(rowOffset, paginationParams.PerPage); err != nil {
+		return err
+	} else {
+		return ctx.JSON(http.StatusOK, users)
+	}
+}
+
+func deleteAdminUser(ctx echo.Context) error {
+	appConfig := ctx.Get("AppConfig").(config.AppConfig)
+	userID, err := getUUIDParam(ctx, "id")
+	if err != nil {
+		return err
+	}
+	if userID == getAuthenticatedUser(ctx).UserID {
+		return ctx.JSON(http.StatusBadRequest, "cannot delete your own account from here")
+	}
+
+	// ensure user exists
+	if _, err := crud.GetUserById(userID); err != nil {
+		return err
+	}
+
+	imageIDs, err := crud.DeleteUser(userID)
+	if err != nil {
+		return err
+	}
+	for _, imageID := range imageIDs {
+		removeRecipeImage(appConfig, imageID)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func postAdminDisableUser(ctx echo.Context) error {
+	userID, err := getUUIDParam(ctx, "id")
+	if err != nil {
+		return err
+	}
+	if userID == getAuthenticatedUser(ctx).UserID {
+		return ctx.JSON(http.StatusBadRequest, "cannot disable your own account")
+	}
+
+	if _, err := crud.GetUserById(userID); err != nil {
+		return err
+	}
+	if err := crud.SetUserDisabled(userID, true); err != nil {
+		return err
+	}
+	// log the user out everywhere
+	if err := crud.RevokeSessionsByUserID(userID); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func postAdminEnableUser(ctx echo.Context) error {
+	userID, err := getUUIDParam(ctx, "id")
+	if err != nil {
+		return err
+	}
+
+	if _, err := crud.GetUserById(userID); err != nil {
+		return err
+	}
+	if err := crud.SetUserDisabled(userID, false); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func postAdminPromoteUser(ctx echo.Context) error {
+	userID, err := getUUIDParam(ctx, "id")
+	if err != nil {
+		return err
+	}
+
+	if _, err := crud.GetUserById(userID); err != nil {
+		return err
+	}
+	if err := crud.SetUserAdmin(userID, true); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func postAdminDemoteUser(ctx echo.Context) error {
+	userID, err := getUUIDParam(ctx, "id")
+	if err != nil {
+		return err
+	}
+	if userID == getAuthenticatedUser(ctx).UserID {
+		return ctx.JSON(http.StatusBadRequest, "cannot demote your own account")
+	}
+
+	if _, err := crud.GetUserById(userID); err != nil {
+		return err
+	}
+	if err := crud.SetUserAdmin(userID, false); err != nil {
+		return err
+	}
+	// existing tokens still claim admin, so force a new login
+	if err := crud.RevokeSessionsByUserID(userID); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func putAdminUserPassword(ctx echo.Context) error {
+	userID, err := getUUIDParam(ctx, "id")
+	if err != nil {
+		return err
+	}
+
+	var formData db.ResetUserPassword
+	if err := core.BindAndValidate(ctx, &formData); err != nil {
+		return err
+	}
+
+	if _, err := crud.GetUserById(userID); err != nil {
+		return err
+	}
+	if err := crud.UpdateUserPassword(userID, formData.Password); err != nil {
+		return err
+	}
+	if err := crud.RevokeSessionsByUserID(userID); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func getAdminInvites(ctx echo.Context) error {
+	if invites, err := crud.GetInviteCodes(); err != nil {
+		return err
+	} else {
+		return ctx.JSON(http.StatusOK, invites)
+	}
+}
+
+func postAdminCreateInvite(ctx echo.Context) error {
+	authenticatedUser := getAuthenticatedUser(ctx)
+
+	var formData db.CreateInviteCode
+	if err := core.BindAndValidate(ctx, &formData); err != nil {
+		return err
+	}
+
+	if invite, err := crud.CreateInviteCode(formData, authenticatedUser.UserID); err != nil {
+		return err
+	} else {
+		return ctx.JSON(http.StatusCreated, invite)
+	}
+}
+
+func deleteAdminInvite(ctx echo.Context) error {
+	inviteID, err := getUUIDParam(ctx, "id")
+	if err != nil {
+		return err
+	}
+
+	if _, err := crud.GetInviteCodeByID(inviteID); err != nil {
+		return err
+	}
+	if err := crud.DeleteInviteCode(inviteID); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
@@ -3,14 +3,62 @@ package routes
 import (
 	"errors"
 	"net/http"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/my-cooking-codex/api/config"
 	"github.com/my-cooking-codex/api/core"
+	"github.com/my-cooking-codex/api/db"
 	"github.com/my-cooking-codex/api/db/crud"
 	"gorm.io/gorm"
 )
 
+// Create the access token for a session, pairing it with the session's refresh token
+func createLoginToken(
+	appConfig config.AppConfig,
+	user db.User,
+	sessionID uuid.UUID,
+	refreshToken string,
+	refreshExpiry time.Time,
+) (core.LoginToken, error) {
+	authenticationData := core.AuthenticatedUser{
+		UserID:    user.ID,
+		Username:  user.Username,
+		IsAdmin:   user.IsAdmin,
+		SessionID: sessionID,
+	}
+	token, err := core.CreateAuthenticationToken(
+		authenticationData,
+		[]byte(appConfig.JWTSecret),
+		appConfig.AccessTokenExpiry,
+	)
+	if err != nil {
+		return core.LoginToken{}, err
+	}
+	token.RefreshToken = refreshToken
+	token.RefreshExpiry = refreshExpiry
+	return token, nil
+}
+
+// Start a new session for the user
+func createLoginSession(appConfig config.AppConfig, user db.User) (core.LoginToken, error) {
+	refreshToken, refreshTokenHash, err := core.CreateOpaqueToken()
+	if err != nil {
+		return core.LoginToken{}, err
+	}
+	refreshExpiry := time.Now().Add(appConfig.RefreshTokenExpiry)
+	session, err := crud.CreateSession(user.ID, refreshTokenHash, refreshExpiry)
+	if err != nil {
+		return core.LoginToken{}, err
+	}
+	// old sessions are no longer useful, so keep the table tidy
+	if err := crud.DeleteStaleSessionsByUserID(user.ID); err != nil {
+		return core.LoginToken{}, err
+	}
+	return createLoginToken(appConfig, user, session.ID, refreshToken, refreshExpiry)
+}
+
 func postLogin(ctx echo.Context) error {
 	appConfig := ctx.Get("AppConfig").(config.AppConfig)
 	var loginData core.CreateLogin
@@ -20,28 +68,126 @@ func postLogin(ctx echo.Context) error {
 
 	// validate username & password
 	user, err := crud.GetUserByUsername(loginData.Username)
-	if err != nil || !user.IsPasswordMatch(loginData.Password) {
-		if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
-			// handle when user does not exist
-			return ctx.NoContent(http.StatusUnauthorized)
-		}
+	isUnknownUser := errors.Is(err, gorm.ErrRecordNotFound)
+	if isUnknownUser {
+		// check a stand-in, so logins for users that don't exist take as
+		// long and are locked out the same as for users that do
+		user = crud.GetUnknownUser(loginData.Username)
+	} else if err != nil {
 		// fallback, handle error in global error handler
 		return err
 	}
+	if user.IsLockedOut() {
+		return respondLockedOut(ctx, user)
+	}
+	if !user.IsPasswordMatch(loginData.Password) || isUnknownUser {
+		if isUnknownUser {
+			recordUnknownUserFailedLogin(appConfig, loginData.Username)
+		} else if err := recordFailedLogin(appConfig, user); err != nil {
+			return err
+		}
+		return ctx.NoContent(http.StatusUnauthorized)
+	}
+	if user.IsDisabled {
+		return ctx.JSON(http.StatusForbidden, "account is disabled")
+	}
 
-	authenticationData := core.AuthenticatedUser{
-		UserID:   user.ID,
-		Username: user.Username,
-		IsAdmin:  false,
+	// password is valid, but a second factor is still needed
+	if user.TOTPEnabled {
+		return respondMFARequired(ctx, appConfig, user)
 	}
 
-	// user is valid, create a token
-	if token, err := core.CreateAuthenticationToken(
-		authenticationData,
+	// user is valid, create a session
+	if err := crud.ResetFailedLogins(user.ID); err != nil {
+		return err
+	}
+	if token, err := createLoginSession(appConfig, user); err != nil {
+		return err
+	} else {
+		return ctx.JSON(http.StatusOK, token)
+	}
+}
+
+// Respond with a token for the second step of a login, see postTOTPLogin
+func respondMFARequired(ctx echo.Context, appConfig config.AppConfig, user db.User) error {
+	if token, err := core.CreateMFAToken(
+		user.ID,
 		[]byte(appConfig.JWTSecret),
+		mfaTokenExpiry,
+	); err != nil {
+		return err
+	} else {
+		return ctx.JSON(http.StatusAccepted, token)
+	}
+}
+
+func postRefreshLogin(ctx echo.Context) error {
+	appConfig := ctx.Get("AppConfig").(config.AppConfig)
+	var refreshData core.RefreshLogin
+	if err := core.BindAndValidate(ctx, &refreshData); err != nil {
+		return err
+	}
+
+	refreshTokenHash := core.HashOpaqueToken(refreshData.RefreshToken)
+	session, err := crud.GetSessionByRefreshTokenHash(refreshTokenHash)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.NoContent(http.StatusUnauthorized)
+		}
+		return err
+	}
+	if !session.IsActive() {
+		return ctx.NoContent(http.StatusUnauthorized)
+	}
+
+	user, err := crud.GetUserById(session.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.NoContent(http.StatusUnauthorized)
+		}
+		return err
+	}
+	if user.IsDisabled {
+		return ctx.NoContent(http.StatusUnauthorized)
+	}
+
+	// rotate the refresh token, so each one can only be used once
+	newRefreshToken, newRefreshTokenHash, err := core.CreateOpaqueToken()
+	if err != nil {
+		return err
+	}
+	refreshExpiry := time.Now().Add(appConfig.RefreshTokenExpiry)
+	if rotated, err := crud.RotateSessionRefreshToken(
+		session.ID,
+		refreshTokenHash,
+		newRefreshTokenHash,
+		refreshExpiry,
+	); err != nil {
+		return err
+	} else if !rotated {
+		// another request used the token first
+		return ctx.NoContent(http.StatusUnauthorized)
+	}
+
+	if token, err := createLoginToken(
+		appConfig,
+		user,
+		session.ID,
+		newRefreshToken,
+		refreshExpiry,
 	); err != nil {
 		return err
 	} else {
 		return ctx.JSON(http.StatusOK, token)
 	}
 }
+
+func postLogout(ctx echo.Context) error {
+	authenticatedUser := getAuthenticatedUser(ctx)
+
+	if err := crud.RevokeSession(authenticatedUser.SessionID); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
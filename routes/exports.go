@@ -0,0 +1,137 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/my-cooking-codex/api/config"
+	"github.com/my-cooking-codex/api/core"
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/db/crud"
+	"github.com/my-cooking-codex/api/exporters"
+	"github.com/my-cooking-codex/api/policy"
+)
+
+// Get a recipe to export, along with its image
+func getExportRecipe(appConfig config.AppConfig, recipeID uuid.UUID) (exporters.Recipe, error) {
+	recipe, err := crud.GetRecipeById(recipeID)
+	if err != nil {
+		return exporters.Recipe{}, err
+	}
+	exportRecipe := exporters.Recipe{ReadRecipe: recipe}
+	if recipe.ImageID != nil {
+		image, err := os.ReadFile(path.Join(appConfig.Data.RecipeOriginalsPath(), recipe.ImageID.String()+".jpg"))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return exporters.Recipe{}, err
+		}
+		exportRecipe.Image = image
+	}
+	return exportRecipe, nil
+}
+
+// Whether the client asked for schema.org JSON-LD rather than the usual JSON
+func acceptsJSONLD(ctx echo.Context) bool {
+	var schemaQuality, jsonQuality float64
+	for _, mediaRange := range strings.Split(ctx.Request().Header.Get(echo.HeaderAccept), ",") {
+		mediaType, params, _ := strings.Cut(mediaRange, ";")
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if key, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok && key == "q" {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = parsed
+				}
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case exporters.SchemaContentType:
+			schemaQuality = quality
+		case echo.MIMEApplicationJSON:
+			jsonQuality = quality
+		}
+	}
+	return schemaQuality > 0 && schemaQuality >= jsonQuality
+}
+
+// Send a recipe as schema.org JSON-LD, linking to its image through the given media path
+func sendRecipeSchema(ctx echo.Context, recipe db.ReadRecipe, imagePath string) error {
+	var imageURL string
+	if recipe.ImageID != nil {
+		imageURL = ctx.Scheme() + "://" + ctx.Request().Host + imagePath
+	}
+	content, err := json.Marshal(exporters.RecipeSchema(recipe, imageURL))
+	if err != nil {
+		return err
+	}
+	return ctx.Blob(http.StatusOK, exporters.SchemaContentType+"; charset=utf-8", content)
+}
+
+func sendExport(ctx echo.Context, format exporters.Format, title string, recipes []exporters.Recipe) error {
+	content, err := exporters.Export(format, title, recipes)
+	if err != nil {
+		return err
+	}
+	ctx.Response().Header().Set(
+		echo.HeaderContentDisposition,
+		fmt.Sprintf(`attachment; filename="%s"`, exporters.FileName(title, format)),
+	)
+	return ctx.Blob(http.StatusOK, format.ContentType(), content)
+}
+
+func getRecipeExport(ctx echo.Context) error {
+	appConfig := ctx.Get("AppConfig").(config.AppConfig)
+	recipeID, err := getUUIDParam(ctx, "id")
+	if err != nil {
+		return err
+	}
+
+	var params core.RecipeExportParams
+	if err := core.BindAndValidate(ctx, &params); err != nil {
+		return err
+	}
+
+	recipe, err := getExportRecipe(appConfig, recipeID)
+	if err != nil {
+		return err
+	}
+	return sendExport(ctx, exporters.Format(params.Format), recipe.Title, []exporters.Recipe{recipe})
+}
+
+func getRecipesExport(ctx echo.Context) error {
+	appConfig := ctx.Get("AppConfig").(config.AppConfig)
+	authenticatedUser := getAuthenticatedUser(ctx)
+
+	var params core.RecipesExportParams
+	if err := core.BindAndValidate(ctx, &params); err != nil {
+		return err
+	}
+	title := params.Title
+	if title == "" {
+		title = "Cookbook"
+	}
+
+	recipes := make([]exporters.Recipe, len(params.IDs))
+	for i, rawID := range params.IDs {
+		recipeID, err := uuid.Parse(rawID)
+		if err != nil {
+			return ctx.JSON(http.StatusBadRequest, "invalid id")
+		}
+		if decision, err := policy.Decide(authenticatedUser.UserID, policy.Recipe, recipeID, policy.Read); err != nil {
+			return err
+		} else if decision != policy.Allowed {
+			return ctx.NoContent(http.StatusNotFound)
+		}
+		if recipes[i], err = getExportRecipe(appConfig, recipeID); err != nil {
+			return err
+		}
+	}
+	return sendExport(ctx, exporters.Format(params.Format), title, recipes)
+}
@@ -0,0 +1,200 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/my-cooking-codex/api/core"
+	"github.com/my-cooking-codex/api/db/crud"
+	"github.com/my-cooking-codex/api/db/types"
+	"github.com/my-cooking-codex/api/policy"
+	"gorm.io/gorm"
+)
+
+// Check the user may add things to a household, always true when none is given
+func canAddToHousehold(userID uuid.UUID, householdID *uuid.UUID) (bool, error) {
+	if householdID == nil {
+		return true, nil
+	}
+	decision, err := policy.Decide(userID, policy.Household, *householdID, policy.Write)
+	return decision == policy.Allowed, err
+}
+
+func getHouseholds(ctx echo.Context) error {
+	authenticatedUser := getAuthenticatedUser(ctx)
+
+	if households, err := crud.GetHouseholdsByUserID(authenticatedUser.UserID); err != nil {
+		return err
+	} else {
+		return ctx.JSON(http.StatusOK, households)
+	}
+}
+
+func postCreateHousehold(ctx echo.Context) error {
+	authenticatedUser := getAuthenticatedUser(ctx)
+
+	var formData types.CreateHousehold
+	if err := core.BindAndValidate(ctx, &formData); err != nil {
+		return err
+	}
+
+	if household, err := crud.CreateHousehold(formData, authenticatedUser.UserID); err != nil {
+		return err
+	} else {
+		return ctx.JSON(http.StatusCreated, household)
+	}
+}
+
+func getHousehold(ctx echo.Context) error {
+	householdID, err := getUUIDParam(ctx, "id")
+	if err != nil {
+		return err
+	}
+	authenticatedUser := getAuthenticatedUser(ctx)
+
+	role, err := crud.GetHouseholdRole(authenticatedUser.UserID, householdID)
+	if err != nil {
+		return err
+	}
+
+	household, err := crud.GetHouseholdByID(householdID)
+	if err != nil {
+		return err
+	}
+	members, err := crud.GetHouseholdMembers(householdID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, types.ReadHouseholdWithMembers{
+		ReadHousehold: types.ReadHousehold{
+			UUIDBase: household.UUIDBase,
+			TimeBase: household.TimeBase,
+			Name:     household.Name,
+			Role:     role,
+		},
+		Members: members,
+	})
+}
+
+func patchHousehold(ctx echo.Context) error {
+	householdID, err := getUUIDParam(ctx, "id")
+	if err != nil {
+		return err
+	}
+	var formData types.UpdateHousehold
+	if err := core.BindAndValidate(ctx, &formData); err != nil {
+		return err
+	}
+
+	if err := crud.UpdateHousehold(householdID, formData); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func deleteHousehold(ctx echo.Context) error {
+	householdID, err := getUUIDParam(ctx, "id")
+	if err != nil {
+		return err
+	}
+	if err := crud.DeleteHousehold(householdID); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func postAddHouseholdMember(ctx echo.Context) error {
+	householdID, err := getUUIDParam(ctx, "id")
+	if err != nil {
+		return err
+	}
+	var formData types.CreateHouseholdMember
+	if err := core.BindAndValidate(ctx, &formData); err != nil {
+		return err
+	}
+
+	user, err := crud.GetUserByUsername(formData.Username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.JSON(http.StatusBadRequest, "user not found")
+		}
+		return err
+	}
+
+	if err := crud.AddHouseholdMember(householdID, user.ID, formData.Role); err != nil {
+		if errors.Is(err, crud.ErrAlreadyHouseholdMember) {
+			return ctx.JSON(http.StatusConflict, err.Error())
+		}
+		return err
+	}
+
+	return ctx.JSON(http.StatusCreated, types.ReadHouseholdMember{
+		UserID:   user.ID,
+		Username: user.Username,
+		Role:     formData.Role,
+	})
+}
+
+func patchHouseholdMember(ctx echo.Context) error {
+	householdID, err := getUUIDParam(ctx, "id")
+	if err != nil {
+		return err
+	}
+	memberID, err := getUUIDParam(ctx, "userId")
+	if err != nil {
+		return err
+	}
+	var formData types.UpdateHouseholdMember
+	if err := core.BindAndValidate(ctx, &formData); err != nil {
+		return err
+	}
+
+	if err := crud.UpdateHouseholdMemberRole(householdID, memberID, formData.Role); err != nil {
+		if errors.Is(err, crud.ErrLastHouseholdOwner) {
+			return ctx.JSON(http.StatusBadRequest, err.Error())
+		}
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// Remove a member, managers can remove anyone and members can remove themselves
+func deleteHouseholdMember(ctx echo.Context) error {
+	householdID, err := getUUIDParam(ctx, "id")
+	if err != nil {
+		return err
+	}
+	memberID, err := getUUIDParam(ctx, "userId")
+	if err != nil {
+		return err
+	}
+	authenticatedUser := getAuthenticatedUser(ctx)
+
+	if memberID != authenticatedUser.UserID {
+		if decision, err := policy.Decide(
+			authenticatedUser.UserID,
+			policy.Household,
+			householdID,
+			policy.Manage,
+		); err != nil {
+			return err
+		} else if decision != policy.Allowed {
+			return ctx.NoContent(http.StatusForbidden)
+		}
+	}
+
+	if err := crud.RemoveHouseholdMember(householdID, memberID); err != nil {
+		if errors.Is(err, crud.ErrLastHouseholdOwner) {
+			return ctx.JSON(http.StatusBadRequest, err.Error())
+		}
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,192 @@
+package routes
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/bytes"
+	"github.com/my-cooking-codex/api/config"
+	"github.com/my-cooking-codex/api/core"
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/db/crud"
+	"github.com/my-cooking-codex/api/importers"
+)
+
+// Largest page that will be read when importing from a url
+const maxImportPageSize = 5 << 20
+
+// How much larger an archive's content may be than the archive,
+// archives of text compress well but a limit stops zip bombs
+const archiveExtractRatio = 4
+
+var recipeFetcher *importers.Fetcher
+
+// Download an imported recipe's image, storing it like an uploaded one
+func downloadRecipeImage(ctx echo.Context, appConfig config.AppConfig, imageURL string) (uuid.UUID, error) {
+	maxSize, err := bytes.Parse(appConfig.ImageUploadSizeLimit)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	content, _, err := recipeFetcher.Get(ctx.Request().Context(), imageURL, maxSize)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	return saveRecipeImage(appConfig, content)
+}
+
+func postImportRecipeURL(ctx echo.Context) error {
+	appConfig := ctx.Get("AppConfig").(config.AppConfig)
+	authenticatedUser := getAuthenticatedUser(ctx)
+
+	var formData db.ImportRecipeURL
+	if err := core.BindAndValidate(ctx, &formData); err != nil {
+		return err
+	}
+
+	if canAdd, err := canAddToHousehold(authenticatedUser.UserID, formData.HouseholdID); err != nil {
+		return err
+	} else if !canAdd {
+		return ctx.JSON(http.StatusBadRequest, "householdId not found, are you a member?")
+	}
+
+	page, pageURL, err := recipeFetcher.Get(ctx.Request().Context(), formData.URL, maxImportPageSize)
+	if err != nil {
+		if errors.Is(err, importers.ErrHostNotAllowed) {
+			return ctx.JSON(http.StatusBadRequest, "url is not allowed")
+		}
+		return ctx.JSON(http.StatusBadGateway, "could not fetch url")
+	}
+
+	imported, err := importers.RecipeFromHTML(page, pageURL)
+	if err != nil {
+		if errors.Is(err, importers.ErrNoRecipe) {
+			return ctx.JSON(http.StatusUnprocessableEntity, "no recipe found at url")
+		}
+		return err
+	}
+	imported.Recipe.HouseholdID = formData.HouseholdID
+
+	recipe, err := crud.CreateRecipe(imported.Recipe, authenticatedUser.UserID)
+	if err != nil {
+		return err
+	}
+
+	// the recipe is still useful without its image, so failures here are only logged
+	if imported.ImageURL != nil {
+		if imageID, err := downloadRecipeImage(ctx, appConfig, *imported.ImageURL); err != nil {
+			ctx.Logger().Warnf("failed to import image '%s': %s", *imported.ImageURL, err)
+		} else if err := crud.UpdateRecipeImage(recipe.ID, &imageID, authenticatedUser.UserID); err != nil {
+			removeRecipeImage(appConfig, imageID)
+			return err
+		} else {
+			recipe.ImageID = &imageID
+		}
+	}
+
+	return ctx.JSON(http.StatusCreated, recipe)
+}
+
+// Create each recipe read from an archive, noting what happened to it
+func importArchiveEntries(ctx echo.Context, entries []importers.ArchiveEntry, userID uuid.UUID, householdID *uuid.UUID) db.ImportReport {
+	appConfig := ctx.Get("AppConfig").(config.AppConfig)
+	report := db.ImportReport{
+		Created: make([]db.ImportReportEntry, 0),
+		Skipped: make([]db.ImportReportEntry, 0),
+		Failed:  make([]db.ImportReportEntry, 0),
+	}
+
+	for _, entry := range entries {
+		if entry.Err != nil {
+			report.Failed = append(report.Failed, db.ImportReportEntry{Name: entry.Name, Reason: entry.Err.Error()})
+			continue
+		}
+		newRecipe := entry.Recipe.Recipe
+		newRecipe.HouseholdID = householdID
+		if err := ctx.Validate(&newRecipe); err != nil {
+			report.Failed = append(report.Failed, db.ImportReportEntry{Name: entry.Name, Reason: errorMessage(err)})
+			continue
+		}
+
+		if exists, err := crud.DoesUserHaveRecipe(userID, newRecipe.Title, newRecipe.Info.Source); err != nil {
+			ctx.Logger().Errorf("failed to check for duplicate of '%s': %s", entry.Name, err)
+			report.Failed = append(report.Failed, db.ImportReportEntry{Name: entry.Name, Reason: "recipe could not be saved"})
+			continue
+		} else if exists {
+			report.Skipped = append(report.Skipped, db.ImportReportEntry{Name: entry.Name, Reason: "a recipe with the same title and source already exists"})
+			continue
+		}
+
+		recipe, err := crud.CreateRecipe(newRecipe, userID)
+		if err != nil {
+			ctx.Logger().Errorf("failed to create imported recipe '%s': %s", entry.Name, err)
+			report.Failed = append(report.Failed, db.ImportReportEntry{Name: entry.Name, Reason: "recipe could not be saved"})
+			continue
+		}
+		created := db.ImportReportEntry{Name: entry.Name, RecipeID: &recipe.ID}
+
+		if len(entry.Recipe.Image) != 0 {
+			if imageID, err := saveRecipeImage(appConfig, entry.Recipe.Image); err != nil {
+				created.Reason = "image could not be imported"
+			} else if err := crud.UpdateRecipeImage(recipe.ID, &imageID, userID); err != nil {
+				removeRecipeImage(appConfig, imageID)
+				created.Reason = "image could not be imported"
+			}
+		}
+		report.Created = append(report.Created, created)
+	}
+	return report
+}
+
+func postImportRecipeArchive(ctx echo.Context) error {
+	appConfig := ctx.Get("AppConfig").(config.AppConfig)
+	authenticatedUser := getAuthenticatedUser(ctx)
+
+	var formData db.ImportRecipeArchive
+	if err := core.BindAndValidate(ctx, &formData); err != nil {
+		return err
+	}
+
+	if canAdd, err := canAddToHousehold(authenticatedUser.UserID, formData.HouseholdID); err != nil {
+		return err
+	} else if !canAdd {
+		return ctx.JSON(http.StatusBadRequest, "householdId not found, are you a member?")
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, "file is required")
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+
+	maxSize, err := bytes.Parse(appConfig.Import.MaxArchiveSize)
+	if err != nil {
+		return err
+	}
+	entries, err := importers.ReadArchive(
+		importers.ArchiveFormat(formData.Format),
+		fileHeader.Filename,
+		content,
+		maxSize*archiveExtractRatio,
+	)
+	if err != nil {
+		if errors.Is(err, importers.ErrArchiveTooLarge) {
+			return ctx.JSON(http.StatusRequestEntityTooLarge, "archive content is too large")
+		} else if errors.Is(err, importers.ErrInvalidArchive) {
+			return ctx.JSON(http.StatusBadRequest, "file is not a valid "+formData.Format+" export")
+		}
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, importArchiveEntries(ctx, entries, authenticatedUser.UserID, formData.HouseholdID))
+}
@@ -0,0 +1,120 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/my-cooking-codex/api/config"
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/db/dbtest"
+)
+
+// A site with a recipe page, whose image is on the same site
+func newRecipeSite(t *testing.T) (*httptest.Server, *int) {
+	t.Helper()
+	var hero bytes.Buffer
+	if err := jpeg.Encode(&hero, image.NewRGBA(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	imageRequests := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/pancakes", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><script type="application/ld+json">{
+			"@context": "https://schema.org",
+			"@graph": [{
+				"@type": "Recipe",
+				"name": "Pancakes",
+				"image": ["/hero.jpg"],
+				"recipeIngredient": ["200g flour", "2 eggs"],
+				"recipeInstructions": "Mix.\nFry."
+			}]
+		}</script></head></html>`))
+	})
+	mux.HandleFunc("/hero.jpg", func(w http.ResponseWriter, r *http.Request) {
+		imageRequests++
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write(hero.Bytes())
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, &imageRequests
+}
+
+func postImportURL(t *testing.T, e *echo.Echo, appConfig config.AppConfig, user db.User, url string) *httptest.ResponseRecorder {
+	t.Helper()
+	token, err := createLoginSession(appConfig, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/recipes/import/url/", strings.NewReader(`{"url":"`+url+`"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token.Token)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestImportRecipeURL(t *testing.T) {
+	dbtest.Setup(t)
+	site, imageRequests := newRecipeSite(t)
+	e, appConfig := newTestServer(t, func(appConfig *config.AppConfig) {
+		appConfig.Import.AllowPrivateHosts = true
+	})
+	user := dbtest.CreateUser(t, "importer")
+
+	rec := postImportURL(t, e, appConfig, user, site.URL+"/pancakes")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+	}
+	var recipe db.ReadRecipe
+	if err := json.Unmarshal(rec.Body.Bytes(), &recipe); err != nil {
+		t.Fatal(err)
+	}
+	if recipe.Title != "Pancakes" {
+		t.Errorf("title = %q, want Pancakes", recipe.Title)
+	}
+	if recipe.Ingredients == nil || len(*recipe.Ingredients) != 2 {
+		t.Errorf("ingredients = %v, want 2", recipe.Ingredients)
+	}
+	if recipe.Steps == nil || len(*recipe.Steps) != 2 {
+		t.Errorf("steps = %v, want 2", recipe.Steps)
+	}
+	if recipe.Info.Source == nil || *recipe.Info.Source != site.URL+"/pancakes" {
+		t.Errorf("source = %v, want the page's url", recipe.Info.Source)
+	}
+
+	// the hero image is downloaded and stored like an uploaded one
+	if *imageRequests != 1 {
+		t.Errorf("image fetched %d times, want 1", *imageRequests)
+	}
+	if recipe.ImageID == nil {
+		t.Fatal("expected the recipe to have an image")
+	}
+	if _, err := os.Stat(path.Join(appConfig.Data.RecipeOriginalsPath(), recipe.ImageID.String()+".jpg")); err != nil {
+		t.Errorf("image not stored: %s", err)
+	}
+}
+
+func TestImportRecipeURLPrivateHost(t *testing.T) {
+	dbtest.Setup(t)
+	site, imageRequests := newRecipeSite(t)
+	e, appConfig := newTestServer(t, nil)
+	user := dbtest.CreateUser(t, "importer")
+
+	rec := postImportURL(t, e, appConfig, user, site.URL+"/pancakes")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if *imageRequests != 0 {
+		t.Errorf("image fetched %d times, want none", *imageRequests)
+	}
+}
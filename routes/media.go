@@ -1,11 +1,13 @@
 package routes
 
 import (
+	"os"
 	"path"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/my-cooking-codex/api/config"
+	"github.com/my-cooking-codex/api/core"
 )
 
 func getRecipeImageContent(ctx echo.Context) error {
@@ -17,3 +19,46 @@ func getRecipeImageContent(ctx echo.Context) error {
 		uuid.MustParse(imageID).String()+".jpg"),
 	)
 }
+
+// Optimise and store an image for a recipe, returning its id
+func saveRecipeImage(appConfig config.AppConfig, content []byte) (uuid.UUID, error) {
+	optimisedContent, err := core.OptimiseImageToJPEG(content, int(appConfig.OptimizedImageSize))
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	imageID := uuid.New()
+	if err := os.WriteFile(path.Join(
+		appConfig.Data.RecipeOriginalsPath(),
+		imageID.String()+".jpg",
+	), optimisedContent, 0644); err != nil {
+		return uuid.UUID{}, err
+	}
+	return imageID, nil
+}
+
+// Remove a stored recipe image, ignoring whether it still exists
+func removeRecipeImage(appConfig config.AppConfig, imageID uuid.UUID) {
+	os.Remove(path.Join(
+		appConfig.Data.RecipeOriginalsPath(),
+		imageID.String()+".jpg",
+	))
+}
+
+// Copy a stored recipe image, returning the id of the copy
+func copyRecipeImage(appConfig config.AppConfig, imageID uuid.UUID) (uuid.UUID, error) {
+	content, err := os.ReadFile(path.Join(
+		appConfig.Data.RecipeOriginalsPath(),
+		imageID.String()+".jpg",
+	))
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	newImageID := uuid.New()
+	if err := os.WriteFile(path.Join(
+		appConfig.Data.RecipeOriginalsPath(),
+		newImageID.String()+".jpg",
+	), content, 0644); err != nil {
+		return uuid.UUID{}, err
+	}
+	return newImageID, nil
+}
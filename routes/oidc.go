@@ -0,0 +1,236 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/my-cooking-codex/api/config"
+	"github.com/my-cooking-codex/api/core"
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/db/crud"
+	"github.com/my-cooking-codex/api/oidc"
+	"gorm.io/gorm"
+)
+
+const oidcLoginRequestExpiry = 10 * time.Minute
+
+// set by InitRoutes when OIDC is configured
+var oidcProvider *oidc.Provider
+
+var nonAlphanumericRegex = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+type oidcAuthorization struct {
+	URL string `json:"url"`
+}
+
+// Pick an unused username for a new user, based on their identity
+func usernameFromClaims(claims oidc.IDTokenClaims) (string, error) {
+	username := ""
+	for _, candidate := range []string{
+		claims.PreferredUsername,
+		strings.Split(claims.Email, "@")[0],
+		claims.Name,
+	} {
+		if username = nonAlphanumericRegex.ReplaceAllString(candidate, ""); username != "" {
+			break
+		}
+	}
+	if len(username) < 3 {
+		username = "user" + username
+	}
+	if len(username) > 24 {
+		username = username[:24]
+	}
+
+	for i := 0; i < 10; i++ {
+		candidate := username
+		if i != 0 {
+			candidate = fmt.Sprintf("%s%d", username, rand.Intn(100000))
+		}
+		if isTaken, err := crud.IsUsernameTaken(candidate); err != nil {
+			return "", err
+		} else if !isTaken {
+			return candidate, nil
+		}
+	}
+	return "", errors.New("could not find a free username")
+}
+
+func startOIDCLogin(ctx echo.Context, linkUserID *uuid.UUID) error {
+	if oidcProvider == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	}
+
+	state, err := oidc.NewRandomString()
+	if err != nil {
+		return err
+	}
+	nonce, err := oidc.NewRandomString()
+	if err != nil {
+		return err
+	}
+	codeVerifier, err := oidc.NewRandomString()
+	if err != nil {
+		return err
+	}
+
+	authorizationURL, err := oidcProvider.AuthorizationURL(
+		ctx.Request().Context(),
+		state,
+		nonce,
+		codeVerifier,
+	)
+	if err != nil {
+		return err
+	}
+	if err := crud.CreateOIDCLoginRequest(
+		state,
+		nonce,
+		codeVerifier,
+		linkUserID,
+		time.Now().Add(oidcLoginRequestExpiry),
+	); err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, oidcAuthorization{URL: authorizationURL})
+}
+
+func getOIDCLogin(ctx echo.Context) error {
+	return startOIDCLogin(ctx, nil)
+}
+
+func getOIDCLink(ctx echo.Context) error {
+	authenticatedUser := getAuthenticatedUser(ctx)
+	return startOIDCLogin(ctx, &authenticatedUser.UserID)
+}
+
+func postOIDCLogin(ctx echo.Context) error {
+	appConfig := ctx.Get("AppConfig").(config.AppConfig)
+	if oidcProvider == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	}
+
+	var formData db.CreateOIDCLogin
+	if err := core.BindAndValidate(ctx, &formData); err != nil {
+		return err
+	}
+
+	loginRequest, err := crud.TakeOIDCLoginRequest(formData.State)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.JSON(http.StatusUnauthorized, "unknown or expired state")
+		}
+		return err
+	}
+
+	claims, err := oidcProvider.Exchange(
+		ctx.Request().Context(),
+		formData.Code,
+		loginRequest.CodeVerifier,
+		loginRequest.Nonce,
+	)
+	if err != nil {
+		ctx.Logger().Error(err)
+		return ctx.JSON(http.StatusUnauthorized, "could not verify login with provider")
+	}
+
+	issuer := oidcProvider.Issuer()
+	user, err := crud.GetUserByIdentity(issuer, claims.Subject)
+	if err == nil {
+		if loginRequest.LinkUserID != nil && *loginRequest.LinkUserID != user.ID {
+			return ctx.JSON(http.StatusConflict, "identity is already linked to another account")
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	} else if loginRequest.LinkUserID != nil {
+		// link the identity to the user that started the login
+		if user, err = crud.GetUserById(*loginRequest.LinkUserID); err != nil {
+			return err
+		}
+		if _, err := crud.CreateUserIdentity(user.ID, issuer, claims.Subject); err != nil {
+			return err
+		}
+	} else if appConfig.OIDC.AutoProvision {
+		username, err := usernameFromClaims(claims)
+		if err != nil {
+			return err
+		}
+		if user, err = crud.CreateUserWithIdentity(
+			username,
+			issuer,
+			claims.Subject,
+			appConfig.RegistrationMode,
+			formData.InviteCode,
+		); err != nil {
+			if errors.Is(err, crud.ErrRegistrationClosed) || errors.Is(err, crud.ErrInviteCodeInvalid) {
+				return ctx.JSON(http.StatusForbidden, err.Error())
+			}
+			return err
+		}
+	} else {
+		return ctx.JSON(http.StatusForbidden, "no account is linked to this identity")
+	}
+
+	if user.IsDisabled {
+		return ctx.JSON(http.StatusForbidden, "account is disabled")
+	}
+	// the identity provider doesn't replace local 2FA
+	if user.TOTPEnabled {
+		return respondMFARequired(ctx, appConfig, user)
+	}
+
+	if token, err := createLoginSession(appConfig, user); err != nil {
+		return err
+	} else {
+		return ctx.JSON(http.StatusOK, token)
+	}
+}
+
+func getUserIdentities(ctx echo.Context) error {
+	authenticatedUser := getAuthenticatedUser(ctx)
+
+	if identities, err := crud.GetUserIdentitiesByUserID(authenticatedUser.UserID); err != nil {
+		return err
+	} else {
+		return ctx.JSON(http.StatusOK, identities)
+	}
+}
+
+func deleteUserIdentity(ctx echo.Context) error {
+	identityID, err := getUUIDParam(ctx, "id")
+	if err != nil {
+		return err
+	}
+	authenticatedUser := getAuthenticatedUser(ctx)
+
+	if isOwner, err := crud.DoesUserOwnIdentity(
+		authenticatedUser.UserID,
+		identityID,
+	); err != nil {
+		return err
+	} else if !isOwner {
+		return ctx.NoContent(http.StatusNotFound)
+	}
+	// users without a password would have no way left to log in
+	if getUser(ctx).IdentityOnly {
+		if count, err := crud.CountUserIdentities(authenticatedUser.UserID); err != nil {
+			return err
+		} else if count <= 1 {
+			return ctx.JSON(http.StatusConflict, "set a password before removing the last identity")
+		}
+	}
+
+	if err := crud.DeleteUserIdentity(identityID); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
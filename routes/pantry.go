@@ -8,6 +8,7 @@ import (
 	"github.com/my-cooking-codex/api/core"
 	"github.com/my-cooking-codex/api/db/crud"
 	"github.com/my-cooking-codex/api/db/types"
+	"github.com/my-cooking-codex/api/policy"
 )
 
 func postCreatePantryLocation(ctx echo.Context) error {
@@ -18,6 +19,12 @@ func postCreatePantryLocation(ctx echo.Context) error {
 		return err
 	}
 
+	if canAdd, err := canAddToHousehold(authenticatedUser.UserID, formData.HouseholdID); err != nil {
+		return err
+	} else if !canAdd {
+		return ctx.JSON(http.StatusBadRequest, "householdId not found, are you a member?")
+	}
+
 	if pantryLocation, err := crud.CreatePantryLocation(
 		formData,
 		authenticatedUser.UserID,
@@ -41,20 +48,10 @@ func getPantryLocations(ctx echo.Context) error {
 }
 
 func getPantryLocationByID(ctx echo.Context) error {
-	pantryLocationID, err := uuid.Parse(ctx.Param("id"))
+	pantryLocationID, err := getUUIDParam(ctx, "id")
 	if err != nil {
 		return err
 	}
-	authenticatedUser := getAuthenticatedUser(ctx)
-
-	if isOwner, err := crud.DoesUserOwnPantryLocation(
-		authenticatedUser.UserID,
-		pantryLocationID,
-	); err != nil {
-		return err
-	} else if !isOwner {
-		return ctx.NoContent(http.StatusNotFound)
-	}
 
 	if pantry, err := crud.GetPantryLocationByID(pantryLocationID); err != nil {
 		return err
@@ -64,20 +61,10 @@ func getPantryLocationByID(ctx echo.Context) error {
 }
 
 func patchPantryLocationByID(ctx echo.Context) error {
-	pantryLocationID, err := uuid.Parse(ctx.Param("id"))
+	pantryLocationID, err := getUUIDParam(ctx, "id")
 	if err != nil {
 		return err
 	}
-	authenticatedUser := getAuthenticatedUser(ctx)
-
-	if isOwner, err := crud.DoesUserOwnPantryLocation(
-		authenticatedUser.UserID,
-		pantryLocationID,
-	); err != nil {
-		return err
-	} else if !isOwner {
-		return ctx.NoContent(http.StatusNotFound)
-	}
 
 	var formData core.SelectedUpdate[types.UpdatePantryLocation]
 	if err := core.BindAndValidate(ctx, &formData); err != nil {
@@ -92,20 +79,10 @@ func patchPantryLocationByID(ctx echo.Context) error {
 }
 
 func deletePantryLocationByID(ctx echo.Context) error {
-	pantryLocationID, err := uuid.Parse(ctx.Param("id"))
+	pantryLocationID, err := getUUIDParam(ctx, "id")
 	if err != nil {
 		return err
 	}
-	authenticatedUser := getAuthenticatedUser(ctx)
-
-	if isOwner, err := crud.DoesUserOwnPantryLocation(
-		authenticatedUser.UserID,
-		pantryLocationID,
-	); err != nil {
-		return err
-	} else if !isOwner {
-		return ctx.NoContent(http.StatusNotFound)
-	}
 
 	if err := crud.DeletePantryLocation(pantryLocationID); err != nil {
 		return err
@@ -114,20 +91,36 @@ func deletePantryLocationByID(ctx echo.Context) error {
 	return ctx.NoContent(http.StatusNoContent)
 }
 
-func postCreatePantryItem(ctx echo.Context) error {
-	pantryLocationID, err := uuid.Parse(ctx.Param("id"))
+func putPantryLocationHousehold(ctx echo.Context) error {
+	pantryLocationID, err := getUUIDParam(ctx, "id")
 	if err != nil {
 		return err
 	}
+
 	authenticatedUser := getAuthenticatedUser(ctx)
 
-	if isOwner, err := crud.DoesUserOwnPantryLocation(
-		authenticatedUser.UserID,
-		pantryLocationID,
-	); err != nil {
+	var formData types.SetHousehold
+	if err := core.BindAndValidate(ctx, &formData); err != nil {
+		return err
+	}
+
+	if canAdd, err := canAddToHousehold(authenticatedUser.UserID, formData.HouseholdID); err != nil {
+		return err
+	} else if !canAdd {
+		return ctx.JSON(http.StatusBadRequest, "householdId not found, are you a member?")
+	}
+
+	if err := crud.SetPantryLocationHousehold(pantryLocationID, formData.HouseholdID); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func postCreatePantryItem(ctx echo.Context) error {
+	pantryLocationID, err := getUUIDParam(ctx, "id")
+	if err != nil {
 		return err
-	} else if !isOwner {
-		return ctx.NoContent(http.StatusNotFound)
 	}
 
 	var formData types.CreatePantryItem
@@ -144,20 +137,10 @@ func postCreatePantryItem(ctx echo.Context) error {
 }
 
 func getPantryItemByID(ctx echo.Context) error {
-	pantryItemID, err := uuid.Parse(ctx.Param("id"))
+	pantryItemID, err := getUUIDParam(ctx, "id")
 	if err != nil {
 		return err
 	}
-	authenticatedUser := getAuthenticatedUser(ctx)
-
-	if isOwner, err := crud.DoesUserOwnPantryItem(
-		authenticatedUser.UserID,
-		pantryItemID,
-	); err != nil {
-		return err
-	} else if !isOwner {
-		return ctx.NoContent(http.StatusNotFound)
-	}
 
 	if pantryItem, err := crud.GetPantryItemByID(pantryItemID); err != nil {
 		return err
@@ -195,20 +178,12 @@ func getPantryItems(ctx echo.Context) error {
 }
 
 func patchPantryItemByID(ctx echo.Context) error {
-	pantryItemID, err := uuid.Parse(ctx.Param("id"))
+	pantryItemID, err := getUUIDParam(ctx, "id")
 	if err != nil {
 		return err
 	}
-	authenticatedUser := getAuthenticatedUser(ctx)
 
-	if isOwner, err := crud.DoesUserOwnPantryItem(
-		authenticatedUser.UserID,
-		pantryItemID,
-	); err != nil {
-		return err
-	} else if !isOwner {
-		return ctx.NoContent(http.StatusNotFound)
-	}
+	authenticatedUser := getAuthenticatedUser(ctx)
 
 	var formData core.SelectedUpdate[types.UpdatePantryItem]
 	if err := core.BindAndValidate(ctx, &formData); err != nil {
@@ -217,12 +192,14 @@ func patchPantryItemByID(ctx echo.Context) error {
 
 	// TODO check "Fields" has "LocationID" instead
 	if formData.Model.LocationId != (uuid.UUID{}) {
-		if isOwner, err := crud.DoesUserOwnPantryLocation(
+		if decision, err := policy.Decide(
 			authenticatedUser.UserID,
+			policy.PantryLocation,
 			formData.Model.LocationId,
+			policy.Write,
 		); err != nil {
 			return err
-		} else if !isOwner {
+		} else if decision != policy.Allowed {
 			return ctx.JSON(http.StatusBadRequest, "locationId not found, are you the owner?")
 		}
 	}
@@ -235,20 +212,10 @@ func patchPantryItemByID(ctx echo.Context) error {
 }
 
 func deletePantryItemByID(ctx echo.Context) error {
-	pantryItemID, err := uuid.Parse(ctx.Param("id"))
+	pantryItemID, err := getUUIDParam(ctx, "id")
 	if err != nil {
 		return err
 	}
-	authenticatedUser := getAuthenticatedUser(ctx)
-
-	if isOwner, err := crud.DoesUserOwnPantryItem(
-		authenticatedUser.UserID,
-		pantryItemID,
-	); err != nil {
-		return err
-	} else if !isOwner {
-		return ctx.NoContent(http.StatusNotFound)
-	}
 
 	if err := crud.DeletePantryItem(pantryItemID); err != nil {
 		return err
@@ -0,0 +1,93 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/my-cooking-codex/api/config"
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/db/crud"
+	"golang.org/x/time/rate"
+)
+
+func setRetryAfter(ctx echo.Context, wait time.Duration) {
+	ctx.Response().Header().Set("Retry-After", fmt.Sprint(int(math.Ceil(wait.Seconds()))))
+}
+
+// Create middleware limiting requests to a number per minute,
+// for each identifier that is extracted from a request
+func newRateLimiter(perMinute uint, extractor middleware.Extractor) echo.MiddlewareFunc {
+	if perMinute == 0 {
+		return func(next echo.HandlerFunc) echo.HandlerFunc {
+			return next
+		}
+	}
+	// time until another request is allowed once the burst is used up
+	refillWait := time.Minute / time.Duration(perMinute)
+	return middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
+		Store: middleware.NewRateLimiterMemoryStoreWithConfig(middleware.RateLimiterMemoryStoreConfig{
+			Rate:      rate.Every(refillWait),
+			Burst:     int(perMinute),
+			ExpiresIn: 3 * time.Minute,
+		}),
+		IdentifierExtractor: extractor,
+		ErrorHandler: func(ctx echo.Context, err error) error {
+			return ctx.NoContent(http.StatusBadRequest)
+		},
+		DenyHandler: func(ctx echo.Context, identifier string, err error) error {
+			setRetryAfter(ctx, refillWait)
+			return ctx.JSON(http.StatusTooManyRequests, "too many requests, try again later")
+		},
+	})
+}
+
+func extractIP(ctx echo.Context) (string, error) {
+	return ctx.RealIP(), nil
+}
+
+// Get the username from a JSON body, leaving the body intact for the handler
+func extractUsername(ctx echo.Context) (string, error) {
+	request := ctx.Request()
+	body, err := io.ReadAll(io.LimitReader(request.Body, 1<<16))
+	if err != nil {
+		return "", err
+	}
+	request.Body = io.NopCloser(bytes.NewReader(body))
+	var data struct {
+		Username string `json:"username"`
+	}
+	// invalid bodies are rejected by the handler
+	json.Unmarshal(body, &data)
+	return strings.ToLower(data.Username), nil
+}
+
+func respondLockedOut(ctx echo.Context, user db.User) error {
+	setRetryAfter(ctx, time.Until(*user.LockedUntil))
+	return ctx.JSON(http.StatusLocked, "account is temporarily locked, try again later")
+}
+
+func recordFailedLogin(appConfig config.AppConfig, user db.User) error {
+	return crud.RecordFailedLogin(
+		user.ID,
+		appConfig.AuthLimits.LockoutThreshold,
+		appConfig.AuthLimits.LockoutBase,
+		appConfig.AuthLimits.LockoutMax,
+	)
+}
+
+func recordUnknownUserFailedLogin(appConfig config.AppConfig, username string) {
+	crud.RecordUnknownUserFailedLogin(
+		username,
+		appConfig.AuthLimits.LockoutThreshold,
+		appConfig.AuthLimits.LockoutBase,
+		appConfig.AuthLimits.LockoutMax,
+	)
+}
@@ -2,10 +2,10 @@ package routes
 
 import (
 	"bytes"
+	"errors"
 	"io"
+	"io/fs"
 	"net/http"
-	"os"
-	"path"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -13,6 +13,10 @@ import (
 	"github.com/my-cooking-codex/api/core"
 	"github.com/my-cooking-codex/api/db"
 	"github.com/my-cooking-codex/api/db/crud"
+	"github.com/my-cooking-codex/api/db/types"
+	"github.com/my-cooking-codex/api/nutrition"
+	"github.com/my-cooking-codex/api/scaling"
+	"github.com/my-cooking-codex/api/units"
 )
 
 func postCreateRecipe(ctx echo.Context) error {
@@ -23,9 +27,25 @@ func postCreateRecipe(ctx echo.Context) error {
 		return err
 	}
 
+	if canAdd, err := canAddToHousehold(authenticatedUser.UserID, recipeData.HouseholdID); err != nil {
+		return err
+	} else if !canAdd {
+		return ctx.JSON(http.StatusBadRequest, "householdId not found, are you a member?")
+	}
+
+	var subRecipeIDs []*uuid.UUID
+	for _, ingredient := range recipeData.Ingredients {
+		subRecipeIDs = append(subRecipeIDs, ingredient.RecipeID)
+	}
+	if canUse, err := canUseSubRecipes(authenticatedUser.UserID, subRecipeIDs); err != nil {
+		return err
+	} else if !canUse {
+		return ctx.JSON(http.StatusBadRequest, crud.ErrSubRecipeNotFound.Error())
+	}
+
 	recipe, err := crud.CreateRecipe(recipeData, authenticatedUser.UserID)
 	if err != nil {
-		return err
+		return recipeErrorResponse(ctx, err)
 	}
 	return ctx.JSON(http.StatusCreated, recipe)
 }
@@ -59,45 +79,77 @@ func getRecipes(ctx echo.Context) error {
 }
 
 func getRecipe(ctx echo.Context) error {
-	recipeID := ctx.Param("id")
-	authenticatedUser := getAuthenticatedUser(ctx)
+	recipeID, err := getUUIDParam(ctx, "id")
+	if err != nil {
+		return err
+	}
 
-	if isOwner, err := crud.DoesUserOwnRecipe(
-		authenticatedUser.UserID,
-		uuid.MustParse(recipeID),
-	); err != nil {
+	var params core.RecipeReadParams
+	if err := core.BindAndValidate(ctx, &params); err != nil {
 		return err
-	} else if !isOwner {
-		return ctx.NoContent(http.StatusNotFound)
 	}
 
-	recipe, err := crud.GetRecipeById(uuid.MustParse(recipeID))
+	recipe, err := crud.GetRecipeById(recipeID)
 	if err != nil {
 		return err
 	}
+	if params.Servings != 0 {
+		factor, ok := scaling.ServingsFactor(recipe, params.Servings)
+		if !ok {
+			return ctx.JSON(http.StatusBadRequest, "recipe has no yields to scale by servings")
+		}
+		recipe = scaling.ScaleRecipe(recipe, factor)
+	} else if params.Scale != 0 {
+		recipe = scaling.ScaleRecipe(recipe, params.Scale)
+	}
+	if recipe.SubRecipes, err = expandSubRecipes(readableBy(getAuthenticatedUser(ctx).UserID), recipe, nil); err != nil {
+		return err
+	}
+	recipe.DetectStepTimers()
+	estimate := nutrition.Estimate(recipe)
+	recipe.Nutrition = &estimate
+	if params.Units != "" {
+		recipe = units.ConvertRecipe(recipe, units.System(params.Units))
+	}
+
+	ctx.Response().Header().Add(echo.HeaderVary, echo.HeaderAccept)
+	if acceptsJSONLD(ctx) {
+		var imagePath string
+		if recipe.ImageID != nil {
+			imagePath = "/media/recipe-image/" + recipe.ImageID.String()
+		}
+		return sendRecipeSchema(ctx, recipe, imagePath)
+	}
 	return ctx.JSON(http.StatusOK, recipe)
 }
 
 func patchRecipe(ctx echo.Context) error {
-	recipeID := ctx.Param("id")
-	authenticatedUser := getAuthenticatedUser(ctx)
-
-	if isOwner, err := crud.DoesUserOwnRecipe(
-		authenticatedUser.UserID,
-		uuid.MustParse(recipeID),
-	); err != nil {
+	recipeID, err := getUUIDParam(ctx, "id")
+	if err != nil {
 		return err
-	} else if !isOwner {
-		return ctx.NoContent(http.StatusNotFound)
 	}
 
+	authenticatedUser := getAuthenticatedUser(ctx)
+
 	var recipeData db.UpdateRecipe
 	if err := core.BindAndValidate(ctx, &recipeData); err != nil {
 		return err
 	}
 
-	if _, err := crud.UpdateRecipe(uuid.MustParse(recipeID), recipeData); err != nil {
-		return err
+	if recipeData.Ingredients != nil {
+		var subRecipeIDs []*uuid.UUID
+		for _, ingredient := range *recipeData.Ingredients {
+			subRecipeIDs = append(subRecipeIDs, ingredient.RecipeID)
+		}
+		if canUse, err := canUseSubRecipes(authenticatedUser.UserID, subRecipeIDs); err != nil {
+			return err
+		} else if !canUse {
+			return ctx.JSON(http.StatusBadRequest, crud.ErrSubRecipeNotFound.Error())
+		}
+	}
+
+	if _, err := crud.UpdateRecipe(recipeID, recipeData, authenticatedUser.UserID); err != nil {
+		return recipeErrorResponse(ctx, err)
 	}
 
 	return ctx.NoContent(http.StatusNoContent)
@@ -105,50 +157,108 @@ func patchRecipe(ctx echo.Context) error {
 
 func deleteRecipe(ctx echo.Context) error {
 	appConfig := ctx.Get("AppConfig").(config.AppConfig)
-	recipeID := ctx.Param("id")
+	recipeID, err := getUUIDParam(ctx, "id")
+	if err != nil {
+		return err
+	}
+
+	recipe, err := crud.GetRecipeById(recipeID)
+	if err != nil {
+		return err
+	}
+
+	if err := crud.DeleteRecipe(recipeID); err != nil {
+		return err
+	}
+
+	if recipe.ImageID != nil {
+		removeRecipeImage(appConfig, *recipe.ImageID)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// Copy a recipe for the user, along with its image
+func copyRecipe(ctx echo.Context, recipeID uuid.UUID) error {
+	appConfig := ctx.Get("AppConfig").(config.AppConfig)
 	authenticatedUser := getAuthenticatedUser(ctx)
 
-	if isOwner, err := crud.DoesUserOwnRecipe(
-		authenticatedUser.UserID,
-		uuid.MustParse(recipeID),
-	); err != nil {
+	recipe, err := crud.GetRecipeById(recipeID)
+	if err != nil {
+		return err
+	}
+
+	var ingredients []db.RecipeIngredient
+	if recipe.Ingredients != nil {
+		if ingredients, err = readableSubRecipes(authenticatedUser.UserID, *recipe.Ingredients); err != nil {
+			return err
+		}
+	}
+
+	var imageID *uuid.UUID
+	if recipe.ImageID != nil {
+		if newImageID, err := copyRecipeImage(appConfig, *recipe.ImageID); err == nil {
+			imageID = &newImageID
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	newRecipe, err := crud.CopyRecipe(recipeID, authenticatedUser.UserID, imageID, ingredients)
+	if err != nil {
+		if imageID != nil {
+			removeRecipeImage(appConfig, *imageID)
+		}
+		return recipeErrorResponse(ctx, err)
+	}
+	return ctx.JSON(http.StatusCreated, newRecipe)
+}
+
+func postCopyRecipe(ctx echo.Context) error {
+	recipeID, err := getUUIDParam(ctx, "id")
+	if err != nil {
 		return err
-	} else if !isOwner {
-		return ctx.NoContent(http.StatusNotFound)
 	}
 
-	recipe, err := crud.GetRecipeById(uuid.MustParse(recipeID))
+	return copyRecipe(ctx, recipeID)
+}
+
+func putRecipeHousehold(ctx echo.Context) error {
+	recipeID, err := getUUIDParam(ctx, "id")
 	if err != nil {
 		return err
 	}
 
-	if err := crud.DeleteRecipe(uuid.MustParse(recipeID)); err != nil {
+	authenticatedUser := getAuthenticatedUser(ctx)
+
+	var formData types.SetHousehold
+	if err := core.BindAndValidate(ctx, &formData); err != nil {
+		return err
+	}
+
+	if canAdd, err := canAddToHousehold(authenticatedUser.UserID, formData.HouseholdID); err != nil {
 		return err
+	} else if !canAdd {
+		return ctx.JSON(http.StatusBadRequest, "householdId not found, are you a member?")
 	}
 
-	os.Remove(path.Join(
-		appConfig.Data.RecipeOriginalsPath(),
-		recipe.ImageID.String()+".jpg",
-	))
+	if err := crud.SetRecipeHousehold(recipeID, formData.HouseholdID, authenticatedUser.UserID); err != nil {
+		return err
+	}
 
 	return ctx.NoContent(http.StatusNoContent)
 }
 
 func postSetRecipeImage(ctx echo.Context) error {
 	appConfig := ctx.Get("AppConfig").(config.AppConfig)
-	recipeID := ctx.Param("id")
-	authenticatedUser := getAuthenticatedUser(ctx)
-
-	if isOwner, err := crud.DoesUserOwnRecipe(
-		authenticatedUser.UserID,
-		uuid.MustParse(recipeID),
-	); err != nil {
+	recipeID, err := getUUIDParam(ctx, "id")
+	if err != nil {
 		return err
-	} else if !isOwner {
-		return ctx.NoContent(http.StatusNotFound)
 	}
 
-	recipe, err := crud.GetRecipeById(uuid.MustParse(recipeID))
+	authenticatedUser := getAuthenticatedUser(ctx)
+
+	recipe, err := crud.GetRecipeById(recipeID)
 	if err != nil {
 		return err
 	}
@@ -158,31 +268,18 @@ func postSetRecipeImage(ctx echo.Context) error {
 	var b = bytes.Buffer{}
 	io.Copy(&b, ctx.Request().Body)
 	b.Read(content)
-	if optimisedContent, err := core.OptimiseImageToJPEG(content, int(appConfig.OptimizedImageSize)); err == nil {
-		content = optimisedContent
-	} else {
-		return err
-	}
-
-	imageID := uuid.New()
-	imagePath := path.Join(
-		appConfig.Data.RecipeOriginalsPath(),
-		imageID.String()+".jpg",
-	)
-	if err := os.WriteFile(imagePath, content, 0644); err != nil {
+	imageID, err := saveRecipeImage(appConfig, content)
+	if err != nil {
 		return err
 	}
 
-	if err := crud.UpdateRecipeImage(uuid.MustParse(recipeID), &imageID); err != nil {
+	if err := crud.UpdateRecipeImage(recipeID, &imageID, authenticatedUser.UserID); err != nil {
 		return err
 	}
 
 	// Remove old image if one was set
 	if recipe.ImageID != nil {
-		os.Remove(path.Join(
-			appConfig.Data.RecipeOriginalsPath(),
-			recipe.ImageID.String()+".jpg",
-		))
+		removeRecipeImage(appConfig, *recipe.ImageID)
 	}
 
 	return ctx.JSON(http.StatusCreated, imageID.String())
@@ -190,31 +287,26 @@ func postSetRecipeImage(ctx echo.Context) error {
 
 func deleteRecipeImage(ctx echo.Context) error {
 	appConfig := ctx.Get("AppConfig").(config.AppConfig)
-	recipeID := ctx.Param("id")
-	authenticatedUser := getAuthenticatedUser(ctx)
-
-	if isOwner, err := crud.DoesUserOwnRecipe(
-		authenticatedUser.UserID,
-		uuid.MustParse(recipeID),
-	); err != nil {
+	recipeID, err := getUUIDParam(ctx, "id")
+	if err != nil {
 		return err
-	} else if !isOwner {
-		return ctx.NoContent(http.StatusNotFound)
 	}
 
-	recipe, err := crud.GetRecipeById(uuid.MustParse(recipeID))
+	authenticatedUser := getAuthenticatedUser(ctx)
+
+	recipe, err := crud.GetRecipeById(recipeID)
 	if err != nil {
 		return err
 	}
 
-	os.Remove(path.Join(
-		appConfig.Data.RecipeOriginalsPath(),
-		recipe.ImageID.String()+".jpg",
-	))
+	if recipe.ImageID == nil {
+		return ctx.NoContent(http.StatusNoContent)
+	}
 
-	if err := crud.UpdateRecipeImage(uuid.MustParse(recipeID), nil); err != nil {
+	if err := crud.UpdateRecipeImage(recipeID, nil, authenticatedUser.UserID); err != nil {
 		return err
 	}
+	removeRecipeImage(appConfig, *recipe.ImageID)
 
 	return ctx.NoContent(http.StatusNoContent)
 }
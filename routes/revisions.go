@@ -0,0 +1,99 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/my-cooking-codex/api/core"
+	"github.com/my-cooking-codex/api/db/crud"
+)
+
+// Get the revision number path parameter, responding with bad request when invalid
+func getRevisionParam(ctx echo.Context) (uint, error) {
+	number, err := strconv.ParseUint(ctx.Param("rev"), 10, 32)
+	if err != nil || number == 0 {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "invalid rev")
+	}
+	return uint(number), nil
+}
+
+func getRecipeRevisions(ctx echo.Context) error {
+	recipeID, err := getUUIDParam(ctx, "id")
+	if err != nil {
+		return err
+	}
+
+	if revisions, err := crud.GetRecipeRevisions(recipeID); err != nil {
+		return err
+	} else {
+		return ctx.JSON(http.StatusOK, revisions)
+	}
+}
+
+func getRecipeRevision(ctx echo.Context) error {
+	recipeID, err := getUUIDParam(ctx, "id")
+	if err != nil {
+		return err
+	}
+	number, err := getRevisionParam(ctx)
+	if err != nil {
+		return err
+	}
+
+	if revision, err := crud.GetRecipeRevision(recipeID, number); err != nil {
+		return err
+	} else {
+		return ctx.JSON(http.StatusOK, revision)
+	}
+}
+
+func getRecipeRevisionDiff(ctx echo.Context) error {
+	recipeID, err := getUUIDParam(ctx, "id")
+	if err != nil {
+		return err
+	}
+
+	var params core.RecipeRevisionDiffParams
+	if err := core.BindAndValidate(ctx, &params); err != nil {
+		return err
+	}
+
+	from, err := crud.GetRecipeRevision(recipeID, params.From)
+	if err != nil {
+		return err
+	}
+	to, err := crud.GetRecipeRevision(recipeID, params.To)
+	if err != nil {
+		return err
+	}
+
+	fromRecipe := from.Recipe.Data()
+	if changes, err := fromRecipe.Diff(to.Recipe.Data()); err != nil {
+		return err
+	} else {
+		return ctx.JSON(http.StatusOK, changes)
+	}
+}
+
+func postRestoreRecipeRevision(ctx echo.Context) error {
+	recipeID, err := getUUIDParam(ctx, "id")
+	if err != nil {
+		return err
+	}
+	number, err := getRevisionParam(ctx)
+	if err != nil {
+		return err
+	}
+	authenticatedUser := getAuthenticatedUser(ctx)
+
+	if err := crud.RestoreRecipeRevision(recipeID, number, authenticatedUser.UserID); err != nil {
+		return recipeErrorResponse(ctx, err)
+	}
+
+	if recipe, err := crud.GetRecipeById(recipeID); err != nil {
+		return err
+	} else {
+		return ctx.JSON(http.StatusOK, recipe)
+	}
+}
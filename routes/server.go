@@ -4,19 +4,44 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	"github.com/my-cooking-codex/api/config"
 	"github.com/my-cooking-codex/api/core"
+	"github.com/my-cooking-codex/api/db/crud"
 )
 
+type oidcServerInfo struct {
+	ProviderName string `json:"providerName"`
+}
+
 type serverInfo struct {
-	APIVersionMajor     uint `json:"apiVersionMajor"`
-	APIVersionMinor     uint `json:"apiVersionMinor"`
-	RegistrationAllowed bool `json:"registrationAllowed"`
+	APIVersionMajor     uint                    `json:"apiVersionMajor"`
+	APIVersionMinor     uint                    `json:"apiVersionMinor"`
+	RegistrationAllowed bool                    `json:"registrationAllowed"`
+	RegistrationMode    config.RegistrationMode `json:"registrationMode"`
+	OIDC                *oidcServerInfo         `json:"oidc,omitempty"`
 }
 
 func getServerInfo(ctx echo.Context) error {
+	appConfig := ctx.Get("AppConfig").(config.AppConfig)
+
+	registrationMode := appConfig.RegistrationMode
+	if userCount, err := crud.GetUserCount(); err != nil {
+		return err
+	} else if userCount == 0 {
+		// first user can always register
+		registrationMode = config.RegistrationOpen
+	}
+
+	var oidcInfo *oidcServerInfo
+	if appConfig.OIDC.IsEnabled() {
+		oidcInfo = &oidcServerInfo{ProviderName: appConfig.OIDC.ProviderName}
+	}
+
 	return ctx.JSON(http.StatusOK, serverInfo{
 		APIVersionMajor:     core.APIVersionMajor,
 		APIVersionMinor:     core.APIVersionMinor,
-		RegistrationAllowed: true,
+		RegistrationAllowed: registrationMode != config.RegistrationClosed,
+		RegistrationMode:    registrationMode,
+		OIDC:                oidcInfo,
 	})
 }
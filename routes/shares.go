@@ -0,0 +1,129 @@
+package routes
+
+import (
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/my-cooking-codex/api/config"
+	"github.com/my-cooking-codex/api/core"
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/db/crud"
+	"github.com/my-cooking-codex/api/nutrition"
+)
+
+func getRecipeShares(ctx echo.Context) error {
+	recipeID, err := getUUIDParam(ctx, "id")
+	if err != nil {
+		return err
+	}
+
+	if shares, err := crud.GetRecipeSharesByRecipeID(recipeID); err != nil {
+		return err
+	} else {
+		return ctx.JSON(http.StatusOK, shares)
+	}
+}
+
+func postCreateRecipeShare(ctx echo.Context) error {
+	recipeID, err := getUUIDParam(ctx, "id")
+	if err != nil {
+		return err
+	}
+	authenticatedUser := getAuthenticatedUser(ctx)
+
+	var formData db.CreateRecipeShare
+	if err := core.BindAndValidate(ctx, &formData); err != nil {
+		return err
+	}
+	if formData.ExpiresAt != nil && !formData.ExpiresAt.After(time.Now()) {
+		return ctx.JSON(http.StatusBadRequest, "expiresAt must be in the future")
+	}
+
+	if share, err := crud.CreateRecipeShare(formData, recipeID, authenticatedUser.UserID); err != nil {
+		return err
+	} else {
+		return ctx.JSON(http.StatusCreated, share)
+	}
+}
+
+func deleteRecipeShare(ctx echo.Context) error {
+	recipeID, err := getUUIDParam(ctx, "id")
+	if err != nil {
+		return err
+	}
+	shareID, err := getUUIDParam(ctx, "shareId")
+	if err != nil {
+		return err
+	}
+
+	if exists, err := crud.DoesRecipeHaveShare(recipeID, shareID); err != nil {
+		return err
+	} else if !exists {
+		return ctx.NoContent(http.StatusNotFound)
+	}
+
+	if err := crud.DeleteRecipeShare(shareID); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// Get the recipe a share link points to, without needing an account
+func getSharedRecipe(ctx echo.Context) error {
+	share, err := crud.GetActiveRecipeShareByToken(ctx.Param("token"))
+	if err != nil {
+		return err
+	}
+
+	recipe, err := crud.GetRecipeById(share.RecipeID)
+	if err != nil {
+		return err
+	}
+	// only the owner's own recipes are included, not every one the person who shared it can see
+	if recipe.SubRecipes, err = expandSubRecipes(ownedBy(recipe.OwnerID), recipe, nil); err != nil {
+		return err
+	}
+	recipe.DetectStepTimers()
+	estimate := nutrition.Estimate(recipe)
+	recipe.Nutrition = &estimate
+
+	ctx.Response().Header().Add(echo.HeaderVary, echo.HeaderAccept)
+	if acceptsJSONLD(ctx) {
+		return sendRecipeSchema(ctx, recipe, "/media/shared/"+share.Token+"/recipe-image")
+	}
+	return ctx.JSON(http.StatusOK, recipe.IntoReadSharedRecipe())
+}
+
+// Copy the recipe a share link points to into the user's own recipes
+func postCopySharedRecipe(ctx echo.Context) error {
+	share, err := crud.GetActiveRecipeShareByToken(ctx.Param("token"))
+	if err != nil {
+		return err
+	}
+
+	return copyRecipe(ctx, share.RecipeID)
+}
+
+func getSharedRecipeImageContent(ctx echo.Context) error {
+	appConfig := ctx.Get("AppConfig").(config.AppConfig)
+
+	share, err := crud.GetActiveRecipeShareByToken(ctx.Param("token"))
+	if err != nil {
+		return err
+	}
+
+	recipe, err := crud.GetRecipeById(share.RecipeID)
+	if err != nil {
+		return err
+	} else if recipe.ImageID == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	}
+
+	return ctx.File(path.Join(
+		appConfig.Data.RecipeOriginalsPath(),
+		recipe.ImageID.String()+".jpg"),
+	)
+}
@@ -0,0 +1,112 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/db/crud"
+	"github.com/my-cooking-codex/api/db/dbtest"
+	"github.com/my-cooking-codex/api/db/types"
+)
+
+func TestSharedRecipeSubRecipes(t *testing.T) {
+	dbtest.Setup(t)
+	e, appConfig := newTestServer(t, nil)
+	owner := dbtest.CreateUser(t, "owner")
+	member := dbtest.CreateUser(t, "member")
+	copier := dbtest.CreateUser(t, "copier")
+
+	// the owner's soup uses their own sauce, and stock a household member made
+	household, err := crud.CreateHousehold(types.CreateHousehold{Name: "Home"}, owner.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := crud.AddHouseholdMember(household.ID, member.ID, db.HouseholdEditor); err != nil {
+		t.Fatal(err)
+	}
+	stock, err := crud.CreateRecipe(db.CreateRecipe{Title: "Stock", HouseholdID: &household.ID}, member.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sauce, err := crud.CreateRecipe(db.CreateRecipe{Title: "Sauce"}, owner.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	soup, err := crud.CreateRecipe(db.CreateRecipe{
+		Title: "Soup",
+		Ingredients: []db.RecipeIngredient{
+			{Name: "sauce", Amount: 1, RecipeID: &sauce.ID},
+			{Name: "stock", Amount: 1, RecipeID: &stock.ID},
+		},
+	}, owner.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share, err := crud.CreateRecipeShare(db.CreateRecipeShare{}, soup.ID, owner.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(method string, path string, body string, user *db.User) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if user != nil {
+			token, err := createLoginSession(appConfig, *user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token.Token)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("view", func(t *testing.T) {
+		rec := request(http.MethodGet, "/api/shared/recipes/"+share.Token+"/", "", nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+		}
+		var shared db.ReadSharedRecipe
+		if err := json.Unmarshal(rec.Body.Bytes(), &shared); err != nil {
+			t.Fatal(err)
+		}
+		// the member's stock was never shared, so only the owner's sauce is expanded
+		if len(shared.SubRecipes) != 1 || shared.SubRecipes[0].RecipeID != sauce.ID {
+			t.Errorf("sub recipes = %+v, want only the sauce", shared.SubRecipes)
+		}
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		rec := request(http.MethodPost, "/api/shared/recipes/"+share.Token+"/copy/", "", &copier)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+		}
+		var copied db.ReadRecipe
+		if err := json.Unmarshal(rec.Body.Bytes(), &copied); err != nil {
+			t.Fatal(err)
+		}
+		// the copier can't read either linked recipe, so only the names are kept
+		for i, ingredient := range *copied.Ingredients {
+			if ingredient.RecipeID != nil {
+				t.Errorf("ingredient %d linked to %s, want no link", i, ingredient.RecipeID)
+			}
+			if want := (*soup.Ingredients)[i].Name; ingredient.Name != want {
+				t.Errorf("ingredient %d name = %q, want %q", i, ingredient.Name, want)
+			}
+		}
+
+		// the copy can be saved with its ingredients unchanged
+		ingredients, _ := json.Marshal(copied.Ingredients)
+		rec = request(http.MethodPatch, "/api/recipes/"+copied.ID.String()+"/", `{"ingredients":`+string(ingredients)+`}`, &copier)
+		if rec.Code != http.StatusNoContent {
+			t.Errorf("update status = %d, want %d: %s", rec.Code, http.StatusNoContent, rec.Body)
+		}
+	})
+}
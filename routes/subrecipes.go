@@ -0,0 +1,134 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/db/crud"
+	"github.com/my-cooking-codex/api/policy"
+	"github.com/my-cooking-codex/api/scaling"
+	"gorm.io/gorm"
+)
+
+// How deep recipes used as ingredients are followed when expanding them
+const maxSubRecipeDepth = 5
+
+// Whether the user can read every recipe they are using as an ingredient
+func canUseSubRecipes(userID uuid.UUID, recipeIDs []*uuid.UUID) (bool, error) {
+	for _, recipeID := range recipeIDs {
+		if recipeID == nil {
+			continue
+		}
+		if decision, err := policy.Decide(userID, policy.Recipe, *recipeID, policy.Read); err != nil {
+			return false, err
+		} else if decision != policy.Allowed {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Remove links to recipes the user can't read from ingredients, keeping their names
+func readableSubRecipes(userID uuid.UUID, ingredients []db.RecipeIngredient) ([]db.RecipeIngredient, error) {
+	readable := make([]db.RecipeIngredient, len(ingredients))
+	for i, ingredient := range ingredients {
+		if ingredient.RecipeID != nil {
+			if decision, err := policy.Decide(userID, policy.Recipe, *ingredient.RecipeID, policy.Read); err != nil {
+				return nil, err
+			} else if decision != policy.Allowed {
+				ingredient.RecipeID = nil
+			}
+		}
+		readable[i] = ingredient
+	}
+	return readable, nil
+}
+
+// Respond to errors from saving a recipe's ingredients and steps that the user can fix
+func recipeErrorResponse(ctx echo.Context, err error) error {
+	if errors.Is(err, crud.ErrSubRecipeNotFound) ||
+		errors.Is(err, crud.ErrRecipeCycle) ||
+		errors.Is(err, crud.ErrStepIngredientNotFound) ||
+		errors.Is(err, crud.ErrInvalidNutrition) {
+		return ctx.JSON(http.StatusBadRequest, err.Error())
+	}
+	return err
+}
+
+// Decides whether a recipe used as an ingredient is expanded
+type subRecipeFilter func(recipeID uuid.UUID) (bool, error)
+
+// Expand the recipes the user can read
+func readableBy(userID uuid.UUID) subRecipeFilter {
+	return func(recipeID uuid.UUID) (bool, error) {
+		decision, err := policy.Decide(userID, policy.Recipe, recipeID, policy.Read)
+		return decision == policy.Allowed, err
+	}
+}
+
+// Expand only the recipes the user owns, for recipes seen without an account,
+// as recipes in their households were never shared
+func ownedBy(ownerID uuid.UUID) subRecipeFilter {
+	return func(recipeID uuid.UUID) (bool, error) {
+		recipe, err := crud.GetRecipeById(recipeID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		return recipe.OwnerID == ownerID, nil
+	}
+}
+
+// Expand the recipes used as ingredients that pass the filter, each scaled
+// to the amount used. Recipes they use themselves are listed before them
+func expandSubRecipes(canExpand subRecipeFilter, recipe db.ReadRecipe, path []uint) ([]db.SubRecipe, error) {
+	if recipe.Ingredients == nil || len(path) >= maxSubRecipeDepth {
+		return nil, nil
+	}
+
+	var subRecipes []db.SubRecipe
+	for i, ingredient := range *recipe.Ingredients {
+		if ingredient.RecipeID == nil {
+			continue
+		}
+		if ok, err := canExpand(*ingredient.RecipeID); err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+		linked, err := crud.GetRecipeById(*ingredient.RecipeID)
+		if err != nil {
+			return nil, err
+		}
+
+		factor := scaling.SubRecipeFactor(ingredient, linked)
+		linked = scaling.ScaleRecipe(linked, factor)
+		ingredientPath := append(append([]uint{}, path...), uint(i))
+		nested, err := expandSubRecipes(canExpand, linked, ingredientPath)
+		if err != nil {
+			return nil, err
+		}
+		subRecipes = append(subRecipes, nested...)
+
+		subRecipe := db.SubRecipe{
+			RecipeID:    linked.ID,
+			Path:        ingredientPath,
+			Title:       linked.Title,
+			Scale:       factor,
+			Ingredients: []db.RecipeIngredient{},
+			Steps:       []db.RecipeStep{},
+		}
+		if linked.Ingredients != nil {
+			subRecipe.Ingredients = *linked.Ingredients
+		}
+		if linked.Steps != nil {
+			subRecipe.Steps = *linked.Steps
+		}
+		subRecipes = append(subRecipes, subRecipe)
+	}
+	return subRecipes, nil
+}
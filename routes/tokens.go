@@ -0,0 +1,58 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/my-cooking-codex/api/core"
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/db/crud"
+)
+
+func getAPITokens(ctx echo.Context) error {
+	authenticatedUser := getAuthenticatedUser(ctx)
+
+	if apiTokens, err := crud.GetAPITokensByUserID(authenticatedUser.UserID); err != nil {
+		return err
+	} else {
+		return ctx.JSON(http.StatusOK, apiTokens)
+	}
+}
+
+func postCreateAPIToken(ctx echo.Context) error {
+	authenticatedUser := getAuthenticatedUser(ctx)
+
+	var formData db.CreateAPIToken
+	if err := core.BindAndValidate(ctx, &formData); err != nil {
+		return err
+	}
+
+	if apiToken, err := crud.CreateAPIToken(formData, authenticatedUser.UserID); err != nil {
+		return err
+	} else {
+		return ctx.JSON(http.StatusCreated, apiToken)
+	}
+}
+
+func deleteAPIToken(ctx echo.Context) error {
+	tokenID, err := getUUIDParam(ctx, "id")
+	if err != nil {
+		return err
+	}
+	authenticatedUser := getAuthenticatedUser(ctx)
+
+	if isOwner, err := crud.DoesUserOwnAPIToken(
+		authenticatedUser.UserID,
+		tokenID,
+	); err != nil {
+		return err
+	} else if !isOwner {
+		return ctx.NoContent(http.StatusNotFound)
+	}
+
+	if err := crud.DeleteAPIToken(tokenID); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
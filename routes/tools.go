@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/my-cooking-codex/api/core"
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/ingredients"
+	"github.com/my-cooking-codex/api/units"
+)
+
+// Parse free-text ingredient lines, without saving anything
+func postParseIngredients(ctx echo.Context) error {
+	var formData db.ParseIngredients
+	if err := core.BindAndValidate(ctx, &formData); err != nil {
+		return err
+	}
+
+	parsed := ingredients.ParseText(formData.Text)
+	for i := range parsed {
+		parsed[i].UnitType = units.Normalise(parsed[i].UnitType)
+	}
+	if parsed == nil {
+		parsed = []db.RecipeIngredient{}
+	}
+	return ctx.JSON(http.StatusOK, parsed)
+}
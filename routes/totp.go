@@ -0,0 +1,156 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/my-cooking-codex/api/config"
+	"github.com/my-cooking-codex/api/core"
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/db/crud"
+	"gorm.io/gorm"
+)
+
+const (
+	mfaTokenExpiry    = 5 * time.Minute
+	recoveryCodeCount = 10
+)
+
+func postEnrollTOTP(ctx echo.Context) error {
+	authenticatedUser := getAuthenticatedUser(ctx)
+
+	user, err := crud.GetUserById(authenticatedUser.UserID)
+	if err != nil {
+		return err
+	}
+	if user.TOTPEnabled {
+		return ctx.JSON(http.StatusConflict, "TOTP is already enabled")
+	}
+
+	secret, err := core.NewTOTPSecret()
+	if err != nil {
+		return err
+	}
+	if err := crud.SetUserPendingTOTPSecret(user.ID, secret); err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusCreated, db.TOTPEnrollment{
+		Secret: secret,
+		URI:    core.TOTPURI(secret, core.AppName, user.Username),
+	})
+}
+
+func postVerifyTOTP(ctx echo.Context) error {
+	authenticatedUser := getAuthenticatedUser(ctx)
+
+	var formData db.VerifyTOTP
+	if err := core.BindAndValidate(ctx, &formData); err != nil {
+		return err
+	}
+
+	user, err := crud.GetUserById(authenticatedUser.UserID)
+	if err != nil {
+		return err
+	}
+	if user.TOTPEnabled {
+		return ctx.JSON(http.StatusConflict, "TOTP is already enabled")
+	} else if user.TOTPSecret == nil {
+		return ctx.JSON(http.StatusBadRequest, "TOTP enrollment has not been started")
+	}
+
+	counter, isValid := core.ValidateTOTP(*user.TOTPSecret, formData.Code, time.Now())
+	if !isValid {
+		return ctx.JSON(http.StatusBadRequest, "invalid code")
+	}
+
+	recoveryCodes, err := core.NewRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return err
+	}
+	if err := crud.EnableUserTOTP(user.ID, counter, recoveryCodes); err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, db.TOTPRecoveryCodes{RecoveryCodes: recoveryCodes})
+}
+
+func deleteTOTP(ctx echo.Context) error {
+	authenticatedUser := getAuthenticatedUser(ctx)
+
+	var formData db.DisableTOTP
+	if err := core.BindAndValidate(ctx, &formData); err != nil {
+		return err
+	}
+
+	user, err := crud.GetUserById(authenticatedUser.UserID)
+	if err != nil {
+		return err
+	}
+	if ok, err := confirmUser(ctx, user, formData.Password, "password is incorrect"); !ok || err != nil {
+		return err
+	}
+
+	if err := crud.DisableUserTOTP(user.ID); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// Second step of a login, for users that have TOTP enabled
+func postTOTPLogin(ctx echo.Context) error {
+	appConfig := ctx.Get("AppConfig").(config.AppConfig)
+	var loginData core.CreateTOTPLogin
+	if err := core.BindAndValidate(ctx, &loginData); err != nil {
+		return err
+	}
+
+	userID, err := core.ParseMFAToken(loginData.Token, []byte(appConfig.JWTSecret))
+	if err != nil {
+		return ctx.JSON(http.StatusUnauthorized, "invalid or expired token")
+	}
+	user, err := crud.GetUserById(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.NoContent(http.StatusUnauthorized)
+		}
+		return err
+	}
+	if user.IsDisabled || !user.TOTPEnabled || user.TOTPSecret == nil {
+		return ctx.NoContent(http.StatusUnauthorized)
+	}
+	if user.IsLockedOut() {
+		return respondLockedOut(ctx, user)
+	}
+
+	isValid := false
+	if loginData.Code != "" {
+		var counter uint64
+		if counter, isValid = core.ValidateTOTP(*user.TOTPSecret, loginData.Code, time.Now()); isValid {
+			// prevent a code being replayed
+			if isValid, err = crud.UseUserTOTPCounter(user.ID, counter); err != nil {
+				return err
+			}
+		}
+	} else if isValid, err = crud.UseRecoveryCode(user.ID, loginData.RecoveryCode); err != nil {
+		return err
+	}
+	if !isValid {
+		if err := recordFailedLogin(appConfig, user); err != nil {
+			return err
+		}
+		return ctx.NoContent(http.StatusUnauthorized)
+	}
+
+	if err := crud.ResetFailedLogins(user.ID); err != nil {
+		return err
+	}
+	if token, err := createLoginSession(appConfig, user); err != nil {
+		return err
+	} else {
+		return ctx.JSON(http.StatusOK, token)
+	}
+}
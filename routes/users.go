@@ -1,22 +1,61 @@
 package routes
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/my-cooking-codex/api/config"
 	"github.com/my-cooking-codex/api/core"
 	"github.com/my-cooking-codex/api/db"
 	"github.com/my-cooking-codex/api/db/crud"
+	"gorm.io/gorm"
 )
 
+// How recently a user without a password must have logged in to confirm it's them
+const identityOnlyConfirmAge = 10 * time.Minute
+
+// Confirm it's the user themselves making a change to their account, by their
+// password, or for users without one by having recently logged in through
+// their identity provider. Wrong passwords count towards a lockout like
+// logging in does. Returns false once a response has been sent
+func confirmUser(ctx echo.Context, user db.User, password string, incorrectMessage string) (bool, error) {
+	appConfig := ctx.Get("AppConfig").(config.AppConfig)
+	if user.IdentityOnly {
+		session, err := crud.GetSessionById(getAuthenticatedUser(ctx).SessionID)
+		if err != nil {
+			return false, err
+		}
+		if time.Since(session.CreatedAt) > identityOnlyConfirmAge {
+			return false, ctx.JSON(http.StatusForbidden, "log in again to confirm it's you")
+		}
+		return true, nil
+	}
+	if user.IsLockedOut() {
+		return false, respondLockedOut(ctx, user)
+	}
+	if !user.IsPasswordMatch(password) {
+		if err := recordFailedLogin(appConfig, user); err != nil {
+			return false, err
+		}
+		return false, ctx.JSON(http.StatusForbidden, incorrectMessage)
+	}
+	return true, crud.ResetFailedLogins(user.ID)
+}
+
 func postCreateUser(ctx echo.Context) error {
+	appConfig := ctx.Get("AppConfig").(config.AppConfig)
 	var userData db.CreateUser
 	if err := core.BindAndValidate(ctx, &userData); err != nil {
 		return err
 	}
 
-	user, err := crud.CreateUser(userData)
+	user, err := crud.CreateUser(userData, appConfig.RegistrationMode)
 	if err != nil {
+		if errors.Is(err, crud.ErrRegistrationClosed) || errors.Is(err, crud.ErrInviteCodeInvalid) {
+			return ctx.JSON(http.StatusForbidden, err.Error())
+		}
 		return err
 	}
 
@@ -24,12 +63,76 @@ func postCreateUser(ctx echo.Context) error {
 }
 
 func getUserMe(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, getUser(ctx))
+}
+
+func patchUserMe(ctx echo.Context) error {
 	authenticatedUser := getAuthenticatedUser(ctx)
 
-	user, err := crud.GetRecipeById(authenticatedUser.UserID)
+	var formData db.UpdateUser
+	if err := core.BindAndValidate(ctx, &formData); err != nil {
+		return err
+	}
+
+	user, err := crud.GetUserById(authenticatedUser.UserID)
 	if err != nil {
 		return err
 	}
+	if ok, err := confirmUser(ctx, user, formData.CurrentPassword, "current password is incorrect"); !ok || err != nil {
+		return err
+	}
+
+	if formData.Username != nil && *formData.Username != user.Username {
+		if _, err := crud.GetUserByUsername(*formData.Username); err == nil {
+			return ctx.JSON(http.StatusConflict, "username is already taken")
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err := crud.UpdateUsername(user.ID, *formData.Username); err != nil {
+			return err
+		}
+	}
+
+	if formData.Password != nil {
+		if err := crud.UpdateUserPassword(user.ID, *formData.Password); err != nil {
+			return err
+		}
+		// password may have leaked, so log out everywhere else
+		if err := crud.RevokeOtherSessionsByUserID(
+			user.ID,
+			authenticatedUser.SessionID,
+		); err != nil {
+			return err
+		}
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func deleteUserMe(ctx echo.Context) error {
+	appConfig := ctx.Get("AppConfig").(config.AppConfig)
+	authenticatedUser := getAuthenticatedUser(ctx)
+
+	var formData db.DeleteUser
+	if err := core.BindAndValidate(ctx, &formData); err != nil {
+		return err
+	}
+
+	user, err := crud.GetUserById(authenticatedUser.UserID)
+	if err != nil {
+		return err
+	}
+	if ok, err := confirmUser(ctx, user, formData.Password, "password is incorrect"); !ok || err != nil {
+		return err
+	}
+
+	imageIDs, err := crud.DeleteUser(user.ID)
+	if err != nil {
+		return err
+	}
+	for _, imageID := range imageIDs {
+		removeRecipeImage(appConfig, imageID)
+	}
 
-	return ctx.JSON(http.StatusOK, user)
+	return ctx.NoContent(http.StatusNoContent)
 }
@@ -1,42 +1,213 @@
 package routes
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	echojwt "github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/my-cooking-codex/api/config"
 	"github.com/my-cooking-codex/api/core"
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/db/crud"
+	"github.com/my-cooking-codex/api/importers"
+	"github.com/my-cooking-codex/api/oidc"
+	"github.com/my-cooking-codex/api/policy"
+	"gorm.io/gorm"
 )
 
 const (
 	AuthenticatedUserKey = "AuthenticatedUser"
 	UserTokenKey         = "UserToken"
+	UserKey              = "User"
 )
 
+const apiTokenAuthScheme = "Token "
+
+// Authenticate with a personal access token when one is given,
+// otherwise fall back to the given JWT middleware
+func apiTokenMiddleware(jwtMiddleware echo.MiddlewareFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		jwtNext := jwtMiddleware(next)
+		return func(ctx echo.Context) error {
+			auth := ctx.Request().Header.Get(echo.HeaderAuthorization)
+			if !strings.HasPrefix(auth, apiTokenAuthScheme) {
+				return jwtNext(ctx)
+			}
+
+			apiToken, err := crud.GetAPITokenByRawToken(strings.TrimPrefix(auth, apiTokenAuthScheme))
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ctx.NoContent(http.StatusUnauthorized)
+				}
+				return err
+			}
+			if !apiToken.IsActive() {
+				return ctx.NoContent(http.StatusUnauthorized)
+			}
+			user, err := crud.GetUserByIdCached(apiToken.UserID)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ctx.NoContent(http.StatusUnauthorized)
+				}
+				return err
+			}
+			if user.IsDisabled {
+				return ctx.NoContent(http.StatusUnauthorized)
+			}
+			if err := crud.TouchAPIToken(apiToken.ID); err != nil {
+				return err
+			}
+
+			ctx.Set(UserKey, user)
+			ctx.Set(AuthenticatedUserKey, core.AuthenticatedUser{
+				UserID:     user.ID,
+				Username:   user.Username,
+				IsAdmin:    user.IsAdmin,
+				APITokenID: &apiToken.ID,
+				Scope:      apiToken.Scope,
+			})
+			return next(ctx)
+		}
+	}
+}
+
 func authenticatedUserMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(ctx echo.Context) error {
+		if authenticatedUser, ok := ctx.Get(AuthenticatedUserKey).(core.AuthenticatedUser); ok {
+			// already authenticated by a personal access token, so enforce its scope
+			if !authenticatedUser.CanUseMethod(ctx.Request().Method) {
+				return ctx.JSON(http.StatusForbidden, "token scope does not allow this")
+			}
+			return next(ctx)
+		}
+
 		authenticatedUser, err := core.GetAuthenticatedUserFromContext(ctx)
 		if err != nil {
 			// invalid token contents
 			return ctx.NoContent(http.StatusUnauthorized)
 		}
-		// TODO validate username & userID match in database
+		// reject tokens belonging to a revoked or expired session
+		if isActive, err := crud.IsSessionActive(authenticatedUser.SessionID); err != nil {
+			return err
+		} else if !isActive {
+			return ctx.NoContent(http.StatusUnauthorized)
+		}
+		// reject tokens for users that have since been deleted, disabled or renamed
+		user, err := crud.GetUserByIdCached(authenticatedUser.UserID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ctx.NoContent(http.StatusUnauthorized)
+			}
+			return err
+		}
+		if user.IsDisabled || user.Username != authenticatedUser.Username {
+			return ctx.NoContent(http.StatusUnauthorized)
+		}
+		// token may be older than a change in admin status
+		authenticatedUser.IsAdmin = user.IsAdmin
+		ctx.Set(UserKey, user)
 		ctx.Set(AuthenticatedUserKey, authenticatedUser)
 		return next(ctx)
 	}
 }
 
+// Deny personal access tokens, for routes that manage the account itself
+func sessionOnlyMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		if getAuthenticatedUser(ctx).APITokenID != nil {
+			return ctx.JSON(http.StatusForbidden, "cannot be used with a personal access token")
+		}
+		return next(ctx)
+	}
+}
+
+func adminMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return sessionOnlyMiddleware(func(ctx echo.Context) error {
+		if !getAuthenticatedUser(ctx).IsAdmin {
+			return ctx.NoContent(http.StatusForbidden)
+		}
+		return next(ctx)
+	})
+}
+
+// Only continue when the user may perform the action on the resource given by the "id" path param
+func requireAccess(resource policy.Resource, action policy.Action) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			resourceID, err := getUUIDParam(ctx, "id")
+			if err != nil {
+				return err
+			}
+			decision, err := policy.Decide(getAuthenticatedUser(ctx).UserID, resource, resourceID, action)
+			if err != nil {
+				return err
+			}
+			switch decision {
+			case policy.NotFound:
+				return ctx.NoContent(http.StatusNotFound)
+			case policy.Forbidden:
+				return ctx.NoContent(http.StatusForbidden)
+			}
+			return next(ctx)
+		}
+	}
+}
+
 func getAuthenticatedUser(ctx echo.Context) core.AuthenticatedUser {
 	return ctx.Get(AuthenticatedUserKey).(core.AuthenticatedUser)
 }
 
+// Get the user loaded while authenticating, may be up to a few seconds old
+func getUser(ctx echo.Context) db.User {
+	return ctx.Get(UserKey).(db.User)
+}
+
+// Get the message of an error, without the status code of an HTTP error
+func errorMessage(err error) string {
+	if httpErr, ok := err.(*echo.HTTPError); ok {
+		return fmt.Sprint(httpErr.Message)
+	}
+	return err.Error()
+}
+
+// Get a path parameter as a UUID, responding with bad request when invalid
+func getUUIDParam(ctx echo.Context, name string) (uuid.UUID, error) {
+	value, err := uuid.Parse(ctx.Param(name))
+	if err != nil {
+		return uuid.UUID{}, echo.NewHTTPError(http.StatusBadRequest, "invalid "+name)
+	}
+	return value, nil
+}
+
 func InitRoutes(e *echo.Echo, appConfig config.AppConfig) {
+	ipRateLimiter := newRateLimiter(appConfig.AuthLimits.IPPerMinute, extractIP)
+	usernameRateLimiter := newRateLimiter(appConfig.AuthLimits.UsernamePerMinute, extractUsername)
+
 	e.GET("/api/info/", getServerInfo)
-	e.POST("/api/users/", postCreateUser)
-	e.POST("/api/login/", postLogin)
+	e.POST("/api/users/", postCreateUser, ipRateLimiter)
+	e.POST("/api/login/", postLogin, ipRateLimiter, usernameRateLimiter)
+	e.POST("/api/login/refresh/", postRefreshLogin, ipRateLimiter)
+	e.POST("/api/login/totp/", postTOTPLogin, ipRateLimiter)
+	e.GET("/api/login/oidc/", getOIDCLogin, ipRateLimiter)
+	e.POST("/api/login/oidc/", postOIDCLogin, ipRateLimiter)
+	e.GET("/api/shared/recipes/:token/", getSharedRecipe)
+
+	if appConfig.OIDC.IsEnabled() {
+		oidcProvider = oidc.NewProvider(
+			*appConfig.OIDC.IssuerURL,
+			appConfig.OIDC.ClientID,
+			appConfig.OIDC.ClientSecret,
+			appConfig.OIDC.RedirectURL,
+			appConfig.OIDC.Scopes,
+		)
+	}
+	recipeFetcher = importers.NewFetcher(appConfig.Import.Timeout, appConfig.Import.AllowPrivateHosts)
 
 	config := echojwt.Config{
 		NewClaimsFunc: func(c echo.Context) jwt.Claims {
@@ -47,32 +218,85 @@ func InitRoutes(e *echo.Echo, appConfig config.AppConfig) {
 	}
 	jwtMiddleware := echojwt.WithConfig(config)
 
-	apiRoutes := e.Group("/api/", jwtMiddleware, authenticatedUserMiddleware)
+	apiRoutes := e.Group("/api/", apiTokenMiddleware(jwtMiddleware), authenticatedUserMiddleware)
 	{
+		apiRoutes.POST("logout/", postLogout, sessionOnlyMiddleware)
 		apiRoutes.GET("users/me/", getUserMe)
+		apiRoutes.PATCH("users/me/", patchUserMe, sessionOnlyMiddleware)
+		apiRoutes.DELETE("users/me/", deleteUserMe, sessionOnlyMiddleware)
+		apiRoutes.GET("users/me/export/", getUserMeExport)
+		apiRoutes.POST("users/me/import/", postUserMeImport, middleware.BodyLimit(appConfig.Import.MaxArchiveSize))
+		apiRoutes.GET("users/me/tokens/", getAPITokens, sessionOnlyMiddleware)
+		apiRoutes.POST("users/me/tokens/", postCreateAPIToken, sessionOnlyMiddleware)
+		apiRoutes.DELETE("users/me/tokens/:id/", deleteAPIToken, sessionOnlyMiddleware)
+		apiRoutes.POST("users/me/totp/", postEnrollTOTP, sessionOnlyMiddleware)
+		apiRoutes.POST("users/me/totp/verify/", postVerifyTOTP, sessionOnlyMiddleware)
+		apiRoutes.DELETE("users/me/totp/", deleteTOTP, sessionOnlyMiddleware)
+		apiRoutes.GET("users/me/identities/", getUserIdentities)
+		apiRoutes.GET("users/me/identities/oidc/", getOIDCLink, sessionOnlyMiddleware)
+		apiRoutes.DELETE("users/me/identities/:id/", deleteUserIdentity, sessionOnlyMiddleware)
+		apiRoutes.GET("households/", getHouseholds)
+		apiRoutes.POST("households/", postCreateHousehold)
+		apiRoutes.GET("households/:id/", getHousehold, requireAccess(policy.Household, policy.Read))
+		apiRoutes.PATCH("households/:id/", patchHousehold, requireAccess(policy.Household, policy.Manage))
+		apiRoutes.DELETE("households/:id/", deleteHousehold, requireAccess(policy.Household, policy.Delete))
+		apiRoutes.POST("households/:id/members/", postAddHouseholdMember, requireAccess(policy.Household, policy.Manage))
+		apiRoutes.PATCH("households/:id/members/:userId/", patchHouseholdMember, requireAccess(policy.Household, policy.Manage))
+		apiRoutes.DELETE("households/:id/members/:userId/", deleteHouseholdMember, requireAccess(policy.Household, policy.Read))
 		apiRoutes.GET("labels/", getLabels)
+		apiRoutes.POST("tools/parse-ingredients/", postParseIngredients)
 		apiRoutes.POST("recipes/", postCreateRecipe)
+		apiRoutes.POST("recipes/import/url/", postImportRecipeURL)
+		apiRoutes.POST("recipes/import/archive/", postImportRecipeArchive, middleware.BodyLimit(appConfig.Import.MaxArchiveSize))
 		apiRoutes.GET("recipes/", getRecipes)
-		apiRoutes.GET("recipes/:id/", getRecipe)
-		apiRoutes.PATCH("recipes/:id/", patchRecipe)
-		apiRoutes.DELETE("recipes/:id/", deleteRecipe)
-		apiRoutes.POST("recipes/:id/image/", postSetRecipeImage, middleware.BodyLimit(appConfig.ImageUploadSizeLimit))
-		apiRoutes.DELETE("recipes/:id/image/", deleteRecipeImage)
+		apiRoutes.GET("recipes/export/", getRecipesExport)
+		apiRoutes.GET("recipes/:id/", getRecipe, requireAccess(policy.Recipe, policy.Read))
+		apiRoutes.GET("recipes/:id/export/", getRecipeExport, requireAccess(policy.Recipe, policy.Read))
+		apiRoutes.PATCH("recipes/:id/", patchRecipe, requireAccess(policy.Recipe, policy.Write))
+		apiRoutes.DELETE("recipes/:id/", deleteRecipe, requireAccess(policy.Recipe, policy.Delete))
+		apiRoutes.POST("recipes/:id/image/", postSetRecipeImage, middleware.BodyLimit(appConfig.ImageUploadSizeLimit), requireAccess(policy.Recipe, policy.Write))
+		apiRoutes.DELETE("recipes/:id/image/", deleteRecipeImage, requireAccess(policy.Recipe, policy.Write))
+		apiRoutes.PUT("recipes/:id/household/", putRecipeHousehold, requireAccess(policy.Recipe, policy.Manage))
+		apiRoutes.POST("recipes/:id/copy/", postCopyRecipe, requireAccess(policy.Recipe, policy.Read))
+		apiRoutes.POST("shared/recipes/:token/copy/", postCopySharedRecipe)
+		apiRoutes.GET("recipes/:id/revisions/", getRecipeRevisions, requireAccess(policy.Recipe, policy.Read))
+		apiRoutes.GET("recipes/:id/revisions/diff/", getRecipeRevisionDiff, requireAccess(policy.Recipe, policy.Read))
+		apiRoutes.GET("recipes/:id/revisions/:rev/", getRecipeRevision, requireAccess(policy.Recipe, policy.Read))
+		apiRoutes.POST("recipes/:id/revisions/:rev/restore/", postRestoreRecipeRevision, requireAccess(policy.Recipe, policy.Write))
+		apiRoutes.GET("recipes/:id/shares/", getRecipeShares, requireAccess(policy.Recipe, policy.Manage))
+		apiRoutes.POST("recipes/:id/shares/", postCreateRecipeShare, requireAccess(policy.Recipe, policy.Manage))
+		apiRoutes.DELETE("recipes/:id/shares/:shareId/", deleteRecipeShare, requireAccess(policy.Recipe, policy.Manage))
 		apiRoutes.GET("pantry/", getPantryLocations)
 		apiRoutes.POST("pantry/", postCreatePantryLocation)
-		apiRoutes.GET("pantry/:id/", getPantryLocationByID)
-		apiRoutes.PATCH("pantry/:id/", patchPantryLocationByID)
-		apiRoutes.DELETE("pantry/:id/", deletePantryLocationByID)
-		apiRoutes.POST("pantry/:id/items/", postCreatePantryItem)
+		apiRoutes.GET("pantry/:id/", getPantryLocationByID, requireAccess(policy.PantryLocation, policy.Read))
+		apiRoutes.PATCH("pantry/:id/", patchPantryLocationByID, requireAccess(policy.PantryLocation, policy.Write))
+		apiRoutes.DELETE("pantry/:id/", deletePantryLocationByID, requireAccess(policy.PantryLocation, policy.Delete))
+		apiRoutes.PUT("pantry/:id/household/", putPantryLocationHousehold, requireAccess(policy.PantryLocation, policy.Manage))
+		apiRoutes.POST("pantry/:id/items/", postCreatePantryItem, requireAccess(policy.PantryLocation, policy.Write))
 		apiRoutes.GET("pantry-items/", getPantryItems)
-		apiRoutes.GET("pantry-items/:id/", getPantryItemByID)
-		apiRoutes.PATCH("pantry-items/:id/", patchPantryItemByID)
-		apiRoutes.DELETE("pantry-items/:id/", deletePantryItemByID)
+		apiRoutes.GET("pantry-items/:id/", getPantryItemByID, requireAccess(policy.PantryItem, policy.Read))
+		apiRoutes.PATCH("pantry-items/:id/", patchPantryItemByID, requireAccess(policy.PantryItem, policy.Write))
+		apiRoutes.DELETE("pantry-items/:id/", deletePantryItemByID, requireAccess(policy.PantryItem, policy.Delete))
 		apiRoutes.GET("stats/me/", getAccountStats)
 	}
 
+	adminRoutes := apiRoutes.Group("admin/", adminMiddleware)
+	{
+		adminRoutes.GET("users/", getAdminUsers)
+		adminRoutes.DELETE("users/:id/", deleteAdminUser)
+		adminRoutes.POST("users/:id/disable/", postAdminDisableUser)
+		adminRoutes.POST("users/:id/enable/", postAdminEnableUser)
+		adminRoutes.POST("users/:id/promote/", postAdminPromoteUser)
+		adminRoutes.POST("users/:id/demote/", postAdminDemoteUser)
+		adminRoutes.PUT("users/:id/password/", putAdminUserPassword)
+		adminRoutes.GET("invites/", getAdminInvites)
+		adminRoutes.POST("invites/", postAdminCreateInvite)
+		adminRoutes.DELETE("invites/:id/", deleteAdminInvite)
+	}
+
 	mediaRoutes := e.Group("/media/")
 	{
 		mediaRoutes.GET("recipe-image/:id", getRecipeImageContent)
+		mediaRoutes.GET("shared/:token/recipe-image", getSharedRecipeImageContent)
 	}
 }
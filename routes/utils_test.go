@@ -0,0 +1,196 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/my-cooking-codex/api/config"
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/db/crud"
+	"github.com/my-cooking-codex/api/db/dbtest"
+	"github.com/my-cooking-codex/api/db/types"
+	"github.com/my-cooking-codex/api/policy"
+)
+
+type testValidator struct {
+	validator *validator.Validate
+}
+
+func (v *testValidator) Validate(i interface{}) error {
+	if err := v.validator.Struct(i); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return nil
+}
+
+// Create a server with all the routes as main does, configure may change the defaults
+func newTestServer(t *testing.T, configure func(*config.AppConfig)) (*echo.Echo, config.AppConfig) {
+	t.Helper()
+	appConfig := config.AppConfig{
+		Data:                 config.DataConfig{RecipeImagesBase: t.TempDir()},
+		JWTSecret:            []byte("secret"),
+		AccessTokenExpiry:    time.Hour,
+		RefreshTokenExpiry:   time.Hour,
+		OptimizedImageSize:   2000,
+		ImageUploadSizeLimit: "4M",
+		Import:               config.ImportConfig{MaxArchiveSize: "50M", Timeout: time.Second},
+	}
+	if configure != nil {
+		configure(&appConfig)
+	}
+	if err := os.MkdirAll(appConfig.Data.RecipeOriginalsPath(), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	e := echo.New()
+	e.Validator = &testValidator{validator: validator.New()}
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			ctx.Set("AppConfig", appConfig)
+			return next(ctx)
+		}
+	})
+	InitRoutes(e, appConfig)
+	return e, appConfig
+}
+
+// Ids of one of each kind of resource
+type testResources map[policy.Resource]uuid.UUID
+
+// Create a household owned by the user, holding one of each kind of resource
+func createTestResources(t *testing.T, ownerID uuid.UUID, name string) testResources {
+	t.Helper()
+	household, err := crud.CreateHousehold(types.CreateHousehold{Name: name}, ownerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipe, err := crud.CreateRecipe(db.CreateRecipe{Title: name, HouseholdID: &household.ID}, ownerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	location, err := crud.CreatePantryLocation(types.CreatePantryLocation{Name: name, HouseholdID: &household.ID}, ownerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item, err := crud.CreatePantryItem(types.CreatePantryItem{Name: name}, location.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testResources{
+		policy.Recipe:         recipe.ID,
+		policy.PantryLocation: location.ID,
+		policy.PantryItem:     item.ID,
+		policy.Household:      household.ID,
+	}
+}
+
+func TestRequireAccess(t *testing.T) {
+	dbtest.Setup(t)
+	e, appConfig := newTestServer(t, nil)
+	userA := dbtest.CreateUser(t, "usera")
+	userB := dbtest.CreateUser(t, "userb")
+	private := createTestResources(t, userA.ID, "Private")
+	shared := createTestResources(t, userA.ID, "Shared")
+	if err := crud.AddHouseholdMember(shared[policy.Household], userB.ID, db.HouseholdViewer); err != nil {
+		t.Fatal(err)
+	}
+	token, err := createLoginSession(appConfig, userB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every route guarded by requireAccess, the id is filled in from the resources
+	routes := []struct {
+		method   string
+		path     string
+		resource policy.Resource
+		action   policy.Action
+	}{
+		{http.MethodGet, "/api/households/:id/", policy.Household, policy.Read},
+		{http.MethodPatch, "/api/households/:id/", policy.Household, policy.Manage},
+		{http.MethodDelete, "/api/households/:id/", policy.Household, policy.Delete},
+		{http.MethodPost, "/api/households/:id/members/", policy.Household, policy.Manage},
+		{http.MethodPatch, "/api/households/:id/members/:userId/", policy.Household, policy.Manage},
+		// members can leave themselves, but removing someone else needs manage
+		{http.MethodDelete, "/api/households/:id/members/:userId/", policy.Household, policy.Manage},
+		{http.MethodGet, "/api/recipes/:id/", policy.Recipe, policy.Read},
+		{http.MethodGet, "/api/recipes/:id/export/", policy.Recipe, policy.Read},
+		{http.MethodPatch, "/api/recipes/:id/", policy.Recipe, policy.Write},
+		{http.MethodDelete, "/api/recipes/:id/", policy.Recipe, policy.Delete},
+		{http.MethodPost, "/api/recipes/:id/image/", policy.Recipe, policy.Write},
+		{http.MethodDelete, "/api/recipes/:id/image/", policy.Recipe, policy.Write},
+		{http.MethodPut, "/api/recipes/:id/household/", policy.Recipe, policy.Manage},
+		{http.MethodPost, "/api/recipes/:id/copy/", policy.Recipe, policy.Read},
+		{http.MethodGet, "/api/recipes/:id/revisions/", policy.Recipe, policy.Read},
+		{http.MethodGet, "/api/recipes/:id/revisions/diff/", policy.Recipe, policy.Read},
+		{http.MethodGet, "/api/recipes/:id/revisions/:rev/", policy.Recipe, policy.Read},
+		{http.MethodPost, "/api/recipes/:id/revisions/:rev/restore/", policy.Recipe, policy.Write},
+		{http.MethodGet, "/api/recipes/:id/shares/", policy.Recipe, policy.Manage},
+		{http.MethodPost, "/api/recipes/:id/shares/", policy.Recipe, policy.Manage},
+		{http.MethodDelete, "/api/recipes/:id/shares/:shareId/", policy.Recipe, policy.Manage},
+		{http.MethodGet, "/api/pantry/:id/", policy.PantryLocation, policy.Read},
+		{http.MethodPatch, "/api/pantry/:id/", policy.PantryLocation, policy.Write},
+		{http.MethodDelete, "/api/pantry/:id/", policy.PantryLocation, policy.Delete},
+		{http.MethodPut, "/api/pantry/:id/household/", policy.PantryLocation, policy.Manage},
+		{http.MethodPost, "/api/pantry/:id/items/", policy.PantryLocation, policy.Write},
+		{http.MethodGet, "/api/pantry-items/:id/", policy.PantryItem, policy.Read},
+		{http.MethodPatch, "/api/pantry-items/:id/", policy.PantryItem, policy.Write},
+		{http.MethodDelete, "/api/pantry-items/:id/", policy.PantryItem, policy.Delete},
+	}
+
+	// a new guarded route must be added above
+	known := map[string]bool{}
+	for _, route := range routes {
+		known[route.method+" "+route.path] = true
+	}
+	for _, route := range e.Routes() {
+		isGuarded := false
+		for _, prefix := range []string{"/api/households/:id/", "/api/recipes/:id/", "/api/pantry/:id/", "/api/pantry-items/:id/"} {
+			isGuarded = isGuarded || strings.HasPrefix(route.Path, prefix)
+		}
+		if isGuarded && !known[route.Method+" "+route.Path] {
+			t.Errorf("%s %s is missing from the test", route.Method, route.Path)
+		}
+	}
+
+	request := func(method string, path string, resourceID uuid.UUID) int {
+		path = strings.NewReplacer(
+			":id", resourceID.String(),
+			":userId", userA.ID.String(),
+			":shareId", uuid.NewString(),
+			":rev", "1",
+		).Replace(path)
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token.Token))
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	for _, route := range routes {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			// user B can't see user A's private household or anything in it
+			if code := request(route.method, route.path, private[route.resource]); code != http.StatusNotFound {
+				t.Errorf("private resource status = %d, want %d", code, http.StatusNotFound)
+			}
+			if code := request(route.method, route.path, uuid.New()); code != http.StatusNotFound {
+				t.Errorf("missing resource status = %d, want %d", code, http.StatusNotFound)
+			}
+			// as a viewer user B can see shared resources, but not change them
+			code := request(route.method, route.path, shared[route.resource])
+			if route.action == policy.Read {
+				if code == http.StatusForbidden {
+					t.Errorf("shared resource status = %d, want access", code)
+				}
+			} else if code != http.StatusForbidden {
+				t.Errorf("shared resource status = %d, want %d", code, http.StatusForbidden)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package scaling
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/my-cooking-codex/api/db"
+	"github.com/my-cooking-codex/api/units"
+	"gorm.io/datatypes"
+)
+
+var eggNameRegex = regexp.MustCompile(`(?i)\beggs?\b`)
+
+// Round a scaled ingredient amount, keeping eggs whole
+// even when they are listed without a unit
+func roundAmount(amount float64, ingredient db.RecipeIngredient) float64 {
+	if ingredient.RecipeID != nil {
+		return math.Round(amount*100) / 100
+	}
+	if unit, ok := units.Lookup(ingredient.UnitType); ok && unit.Kind == units.KindCount && eggNameRegex.MatchString(ingredient.Name) {
+		return math.Max(math.Round(amount), 1)
+	}
+	return units.Round(amount, ingredient.UnitType)
+}
+
+// Scale a recipe's ingredients and yields by a factor
+func ScaleRecipe(recipe db.ReadRecipe, factor float64) db.ReadRecipe {
+	if recipe.Ingredients != nil {
+		ingredients := make([]db.RecipeIngredient, len(*recipe.Ingredients))
+		for i, ingredient := range *recipe.Ingredients {
+			if ingredient.Amount != 0 {
+				ingredient.Amount = float32(roundAmount(float64(ingredient.Amount)*factor, ingredient))
+			}
+			if ingredient.Nutrition != nil {
+				nutrition := ingredient.Nutrition.Scale(factor)
+				ingredient.Nutrition = &nutrition
+			}
+			ingredients[i] = ingredient
+		}
+		recipe.Ingredients = &ingredients
+	}
+	if recipe.Info.Yields != nil {
+		yields := recipe.Info.Yields.Data()
+		yields.Value = uint(math.Max(math.Round(float64(yields.Value)*factor), 1))
+		scaledYields := datatypes.NewJSONType(yields)
+		recipe.Info.Yields = &scaledYields
+	}
+	return recipe
+}
+
+// Get the factor that scales a recipe to the given number of servings,
+// false if the recipe doesn't say what it yields
+func ServingsFactor(recipe db.ReadRecipe, servings uint) (float64, bool) {
+	if recipe.Info.Yields == nil || recipe.Info.Yields.Data().Value == 0 {
+		return 0, false
+	}
+	return float64(servings) / float64(recipe.Info.Yields.Data().Value), true
+}
+
+// Get the factor a recipe used as an ingredient is scaled by, from an amount
+// in the recipe's yield unit, otherwise from a number of batches
+func SubRecipeFactor(ingredient db.RecipeIngredient, recipe db.ReadRecipe) float64 {
+	if ingredient.Amount <= 0 {
+		return 1
+	}
+	if recipe.Info.Yields != nil && ingredient.UnitType != "" {
+		yields := recipe.Info.Yields.Data()
+		if yields.Value != 0 && strings.EqualFold(strings.TrimSpace(yields.UnitType), strings.TrimSpace(ingredient.UnitType)) {
+			return float64(ingredient.Amount) / float64(yields.Value)
+		}
+	}
+	return float64(ingredient.Amount)
+}
@@ -0,0 +1,116 @@
+package scaling
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/my-cooking-codex/api/db"
+	"gorm.io/datatypes"
+)
+
+func recipeYielding(value uint, unitType string) db.ReadRecipe {
+	yields := datatypes.NewJSONType(db.RecipeInfoYields{Value: value, UnitType: unitType})
+	return db.ReadRecipe{Info: db.RecipeInfo{Yields: &yields}}
+}
+
+func TestScaleRecipeRounding(t *testing.T) {
+	subRecipeID := uuid.New()
+	tests := []struct {
+		name       string
+		ingredient db.RecipeIngredient
+		factor     float64
+		want       float32
+	}{
+		{"a third of a cup", db.RecipeIngredient{Name: "flour", Amount: 1, UnitType: "cup"}, 1.0 / 3, 1.0 / 3},
+		{"cups to kitchen fractions", db.RecipeIngredient{Name: "milk", Amount: 1.5, UnitType: "cups"}, 1.5, 2.25},
+		{"a tiny amount of a cup", db.RecipeIngredient{Name: "milk", Amount: 1, UnitType: "cup"}, 0.05, 1.0 / 8},
+		{"spoons to quarters", db.RecipeIngredient{Name: "sugar", Amount: 1, UnitType: "tbsp"}, 1.6, 1.5},
+		{"small spoons to eighths", db.RecipeIngredient{Name: "salt", Amount: 1, UnitType: "tsp"}, 0.1, 1.0 / 8},
+		{"grams to steps of 5", db.RecipeIngredient{Name: "flour", Amount: 100, UnitType: "g"}, 1.234, 125},
+		{"grams to steps of 1", db.RecipeIngredient{Name: "butter", Amount: 47, UnitType: "g"}, 1.1, 52},
+		{"eggs stay whole", db.RecipeIngredient{Name: "eggs", Amount: 3, UnitType: ""}, 0.5, 2},
+		{"at least one egg", db.RecipeIngredient{Name: "large egg", Amount: 2, UnitType: "whole"}, 0.1, 1},
+		{"other counts can be halved", db.RecipeIngredient{Name: "onion", Amount: 1}, 0.5, 0.5},
+		{"not an egg", db.RecipeIngredient{Name: "eggplant", Amount: 1}, 0.5, 0.5},
+		{"large counts are whole", db.RecipeIngredient{Name: "onions", Amount: 5}, 0.7, 4},
+		{"unknown units to decimals", db.RecipeIngredient{Name: "stock", Amount: 1, UnitType: "cube"}, 1.0 / 3, 0.33},
+		{"recipes used as ingredients to decimals", db.RecipeIngredient{Name: "sauce", Amount: 1, UnitType: "batch", RecipeID: &subRecipeID}, 1.0 / 3, 0.33},
+		{"no amount stays empty", db.RecipeIngredient{Name: "salt", UnitType: "pinch"}, 2, 0},
+	}
+	for _, test := range tests {
+		recipe := ScaleRecipe(db.ReadRecipe{Ingredients: &[]db.RecipeIngredient{test.ingredient}}, test.factor)
+		if got := (*recipe.Ingredients)[0].Amount; got != test.want {
+			t.Errorf("%s: amount = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestScaleRecipe(t *testing.T) {
+	recipe := recipeYielding(4, "servings")
+	recipe.Ingredients = &[]db.RecipeIngredient{
+		{Name: "flour", Amount: 200, UnitType: "g", Nutrition: &db.Nutrition{Calories: 700, Protein: 20}},
+	}
+	scaled := ScaleRecipe(recipe, 0.5)
+	if yields := scaled.Info.Yields.Data(); yields.Value != 2 || yields.UnitType != "servings" {
+		t.Errorf("yields = %+v, want 2 servings", yields)
+	}
+	ingredient := (*scaled.Ingredients)[0]
+	if ingredient.Amount != 100 {
+		t.Errorf("amount = %v, want 100", ingredient.Amount)
+	}
+	if ingredient.Nutrition.Calories != 350 || ingredient.Nutrition.Protein != 10 {
+		t.Errorf("nutrition = %+v, want half", *ingredient.Nutrition)
+	}
+	// the original is left as it was
+	if (*recipe.Ingredients)[0].Amount != 200 || recipe.Info.Yields.Data().Value != 4 {
+		t.Error("expected the original recipe to be unchanged")
+	}
+
+	if yields := ScaleRecipe(recipe, 0.1).Info.Yields.Data(); yields.Value != 1 {
+		t.Errorf("yields = %d, want at least 1", yields.Value)
+	}
+	if scaled := ScaleRecipe(db.ReadRecipe{}, 2); scaled.Ingredients != nil || scaled.Info.Yields != nil {
+		t.Error("expected an empty recipe to stay empty")
+	}
+}
+
+func TestServingsFactor(t *testing.T) {
+	tests := []struct {
+		name     string
+		recipe   db.ReadRecipe
+		servings uint
+		want     float64
+		ok       bool
+	}{
+		{"fewer", recipeYielding(4, "servings"), 2, 0.5, true},
+		{"more", recipeYielding(4, "servings"), 6, 1.5, true},
+		{"no yields", db.ReadRecipe{}, 2, 0, false},
+		{"yields nothing", recipeYielding(0, "servings"), 2, 0, false},
+	}
+	for _, test := range tests {
+		if got, ok := ServingsFactor(test.recipe, test.servings); got != test.want || ok != test.ok {
+			t.Errorf("%s: factor = %v, %v, want %v, %v", test.name, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestSubRecipeFactor(t *testing.T) {
+	tests := []struct {
+		name       string
+		ingredient db.RecipeIngredient
+		recipe     db.ReadRecipe
+		want       float64
+	}{
+		{"no amount", db.RecipeIngredient{}, recipeYielding(4, "servings"), 1},
+		{"batches", db.RecipeIngredient{Amount: 2}, recipeYielding(4, "servings"), 2},
+		{"in the yield unit", db.RecipeIngredient{Amount: 2, UnitType: " Servings"}, recipeYielding(4, "servings"), 0.5},
+		{"in another unit", db.RecipeIngredient{Amount: 2, UnitType: "cups"}, recipeYielding(4, "servings"), 2},
+		{"no yields", db.RecipeIngredient{Amount: 3, UnitType: "servings"}, db.ReadRecipe{}, 3},
+		{"yields nothing", db.RecipeIngredient{Amount: 3, UnitType: "servings"}, recipeYielding(0, "servings"), 3},
+	}
+	for _, test := range tests {
+		if got := SubRecipeFactor(test.ingredient, test.recipe); got != test.want {
+			t.Errorf("%s: factor = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package units
+
+import (
+	"math"
+
+	"github.com/my-cooking-codex/api/db"
+)
+
+// Pick the unit of a system that reads best for an amount in millilitres or grams
+func bestUnit(kind Kind, system System, amount float64) Unit {
+	switch {
+	case system == SystemMetric && kind == KindVolume:
+		if amount < Litre.Size {
+			return Millilitre
+		}
+		return Litre
+	case system == SystemMetric && kind == KindMass:
+		if amount < Kilogram.Size {
+			return Gram
+		}
+		return Kilogram
+	case system == SystemImperial && kind == KindVolume:
+		if amount < Tablespoon.Size {
+			return Teaspoon
+		} else if amount < Cup.Size/4 {
+			return Tablespoon
+		}
+		return Cup
+	}
+	if amount < Pound.Size {
+		return Ounce
+	}
+	return Pound
+}
+
+// Convert an ingredient to the units of a system. Ingredients usually
+// weighed are weighed in metric and measured by volume in imperial,
+// where their density is known
+func ConvertIngredient(ingredient db.RecipeIngredient, system System) db.RecipeIngredient {
+	unit, ok := Lookup(ingredient.UnitType)
+	// spoons and counts are used the same way in both systems,
+	// recipes used as ingredients are measured in their own terms
+	if !ok || unit.System == SystemNeutral || unit.System == system || ingredient.Amount == 0 || ingredient.RecipeID != nil {
+		return ingredient
+	}
+
+	kind := unit.Kind
+	amount := float64(ingredient.Amount) * unit.Size
+	if density, ok := findDensity(ingredient.Name); ok && density.weighed {
+		if system == SystemMetric && kind == KindVolume {
+			amount, kind = amount*density.gramsPerMl, KindMass
+		} else if system == SystemImperial && kind == KindMass {
+			amount, kind = amount/density.gramsPerMl, KindVolume
+		}
+	}
+
+	target := bestUnit(kind, system, amount)
+	ingredient.Amount = float32(Round(amount/target.Size, target.Name))
+	ingredient.UnitType = target.Name
+	return ingredient
+}
+
+func convertIngredients(ingredients []db.RecipeIngredient, system System) []db.RecipeIngredient {
+	converted := make([]db.RecipeIngredient, len(ingredients))
+	for i, ingredient := range ingredients {
+		converted[i] = ConvertIngredient(ingredient, system)
+	}
+	return converted
+}
+
+// Convert a step's temperature to celsius for metric or fahrenheit for imperial
+func ConvertStep(step db.RecipeStep, system System) db.RecipeStep {
+	if step.Temperature == nil {
+		return step
+	}
+	temperature := *step.Temperature
+	if system == SystemMetric && temperature.Unit == "F" {
+		temperature.Value, temperature.Unit = float32(math.Round(float64(temperature.Value-32)*5/9)), "C"
+	} else if system == SystemImperial && temperature.Unit == "C" {
+		temperature.Value, temperature.Unit = float32(math.Round(float64(temperature.Value)*9/5+32)), "F"
+	}
+	step.Temperature = &temperature
+	return step
+}
+
+func convertSteps(steps []db.RecipeStep, system System) []db.RecipeStep {
+	converted := make([]db.RecipeStep, len(steps))
+	for i, step := range steps {
+		converted[i] = ConvertStep(step, system)
+	}
+	return converted
+}
+
+// Convert a recipe's ingredients and step temperatures to the units
+// of a system, along with those of the recipes it uses
+func ConvertRecipe(recipe db.ReadRecipe, system System) db.ReadRecipe {
+	if recipe.Ingredients != nil {
+		ingredients := convertIngredients(*recipe.Ingredients, system)
+		recipe.Ingredients = &ingredients
+	}
+	if recipe.Steps != nil {
+		steps := convertSteps(*recipe.Steps, system)
+		recipe.Steps = &steps
+	}
+	if recipe.SubRecipes != nil {
+		subRecipes := make([]db.SubRecipe, len(recipe.SubRecipes))
+		for i, subRecipe := range recipe.SubRecipes {
+			subRecipe.Ingredients = convertIngredients(subRecipe.Ingredients, system)
+			subRecipe.Steps = convertSteps(subRecipe.Steps, system)
+			subRecipes[i] = subRecipe
+		}
+		recipe.SubRecipes = subRecipes
+	}
+	return recipe
+}
@@ -0,0 +1,140 @@
+package units
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/my-cooking-codex/api/db"
+)
+
+func TestConvertIngredient(t *testing.T) {
+	recipeID := uuid.New()
+	tests := []struct {
+		name       string
+		ingredient db.RecipeIngredient
+		system     System
+		want       db.RecipeIngredient
+	}{
+		// flour is weighed in metric, so its density is used
+		{
+			"cups of flour to grams",
+			db.RecipeIngredient{Name: "plain flour", Amount: 1, UnitType: "cup"},
+			SystemMetric,
+			db.RecipeIngredient{Name: "plain flour", Amount: 125, UnitType: "g"},
+		},
+		{
+			"grams of flour to cups",
+			db.RecipeIngredient{Name: "flour", Amount: 250, UnitType: "g"},
+			SystemImperial,
+			db.RecipeIngredient{Name: "flour", Amount: 2, UnitType: "cup"},
+		},
+		{
+			"cups of butter to grams",
+			db.RecipeIngredient{Name: "butter", Amount: 1, UnitType: "c"},
+			SystemMetric,
+			db.RecipeIngredient{Name: "butter", Amount: 225, UnitType: "g"},
+		},
+		// milk is measured by volume in both, so it stays a volume
+		{
+			"cups of milk to millilitres",
+			db.RecipeIngredient{Name: "milk", Amount: 2, UnitType: "cups"},
+			SystemMetric,
+			db.RecipeIngredient{Name: "milk", Amount: 475, UnitType: "ml"},
+		},
+		{
+			"litres of water to cups",
+			db.RecipeIngredient{Name: "water", Amount: 1, UnitType: "l"},
+			SystemImperial,
+			db.RecipeIngredient{Name: "water", Amount: 4.25, UnitType: "cup"},
+		},
+		{
+			"millilitres to tablespoons",
+			db.RecipeIngredient{Name: "vinegar", Amount: 30, UnitType: "ml"},
+			SystemImperial,
+			db.RecipeIngredient{Name: "vinegar", Amount: 2, UnitType: "tbsp"},
+		},
+		// without a density, mass stays mass
+		{
+			"grams to ounces",
+			db.RecipeIngredient{Name: "chicken", Amount: 100, UnitType: "g"},
+			SystemImperial,
+			db.RecipeIngredient{Name: "chicken", Amount: 3.5, UnitType: "oz"},
+		},
+		{
+			"grams to pounds",
+			db.RecipeIngredient{Name: "chicken", Amount: 500, UnitType: "g"},
+			SystemImperial,
+			db.RecipeIngredient{Name: "chicken", Amount: 1, UnitType: "lb"},
+		},
+		{
+			"pounds to kilograms",
+			db.RecipeIngredient{Name: "potatoes", Amount: 3, UnitType: "lbs"},
+			SystemMetric,
+			db.RecipeIngredient{Name: "potatoes", Amount: 1.36, UnitType: "kg"},
+		},
+		{
+			"already metric",
+			db.RecipeIngredient{Name: "flour", Amount: 123, UnitType: "grams"},
+			SystemMetric,
+			db.RecipeIngredient{Name: "flour", Amount: 123, UnitType: "grams"},
+		},
+		{
+			"spoons are kept",
+			db.RecipeIngredient{Name: "flour", Amount: 1, UnitType: "tbsp"},
+			SystemMetric,
+			db.RecipeIngredient{Name: "flour", Amount: 1, UnitType: "tbsp"},
+		},
+		{
+			"counts are kept",
+			db.RecipeIngredient{Name: "eggs", Amount: 2},
+			SystemImperial,
+			db.RecipeIngredient{Name: "eggs", Amount: 2},
+		},
+		{
+			"unknown units are kept",
+			db.RecipeIngredient{Name: "stock", Amount: 1, UnitType: "cube"},
+			SystemMetric,
+			db.RecipeIngredient{Name: "stock", Amount: 1, UnitType: "cube"},
+		},
+		{
+			"no amount is kept",
+			db.RecipeIngredient{Name: "milk", UnitType: "cup"},
+			SystemMetric,
+			db.RecipeIngredient{Name: "milk", UnitType: "cup"},
+		},
+		{
+			"recipes used as ingredients are kept",
+			db.RecipeIngredient{Name: "sauce", Amount: 1, UnitType: "cup", RecipeID: &recipeID},
+			SystemMetric,
+			db.RecipeIngredient{Name: "sauce", Amount: 1, UnitType: "cup", RecipeID: &recipeID},
+		},
+	}
+	for _, test := range tests {
+		got := ConvertIngredient(test.ingredient, test.system)
+		if got.Name != test.want.Name || got.Amount != test.want.Amount || got.UnitType != test.want.UnitType || got.RecipeID != test.want.RecipeID {
+			t.Errorf("%s: got %v %s, want %v %s", test.name, got.Amount, got.UnitType, test.want.Amount, test.want.UnitType)
+		}
+	}
+}
+
+func TestConvertStep(t *testing.T) {
+	tests := []struct {
+		temperature db.StepTemperature
+		system      System
+		want        db.StepTemperature
+	}{
+		{db.StepTemperature{Value: 350, Unit: "F"}, SystemMetric, db.StepTemperature{Value: 177, Unit: "C"}},
+		{db.StepTemperature{Value: 180, Unit: "C"}, SystemImperial, db.StepTemperature{Value: 356, Unit: "F"}},
+		{db.StepTemperature{Value: 180, Unit: "C"}, SystemMetric, db.StepTemperature{Value: 180, Unit: "C"}},
+	}
+	for _, test := range tests {
+		temperature := test.temperature
+		got := ConvertStep(db.RecipeStep{Temperature: &temperature}, test.system)
+		if *got.Temperature != test.want {
+			t.Errorf("ConvertStep(%v, %s) = %v, want %v", test.temperature, test.system, *got.Temperature, test.want)
+		}
+	}
+	if step := ConvertStep(db.RecipeStep{Description: "Mix."}, SystemMetric); step.Temperature != nil {
+		t.Error("expected a step without a temperature to stay without one")
+	}
+}
@@ -0,0 +1,89 @@
+package units
+
+import (
+	"regexp"
+	"strings"
+)
+
+type density struct {
+	name string
+	// grams per millilitre
+	gramsPerMl float64
+	// whether it's usually weighed rather than measured by volume in metric recipes
+	weighed bool
+}
+
+// Densities of common ingredients, more specific names come before the
+// names they contain so "brown sugar" is found before "sugar"
+var densities = []density{
+	{"almond flour", 0.41, true},
+	{"bread flour", 0.54, true},
+	{"whole wheat flour", 0.51, true},
+	{"wholemeal flour", 0.51, true},
+	{"rye flour", 0.43, true},
+	{"cornflour", 0.54, true},
+	{"cornstarch", 0.54, true},
+	{"cornmeal", 0.65, true},
+	{"flour", 0.53, true},
+	{"brown sugar", 0.93, true},
+	{"powdered sugar", 0.51, true},
+	{"icing sugar", 0.51, true},
+	{"confectioners sugar", 0.51, true},
+	{"sugar", 0.85, true},
+	{"cocoa", 0.42, true},
+	{"baking powder", 0.81, false},
+	{"baking soda", 0.93, false},
+	{"bicarbonate of soda", 0.93, false},
+	{"salt", 1.22, false},
+	{"yeast", 0.6, false},
+	{"peanut butter", 1.08, true},
+	{"buttermilk", 1.03, false},
+	{"butter", 0.96, true},
+	{"rolled oats", 0.38, true},
+	{"oats", 0.38, true},
+	{"rice", 0.85, true},
+	{"couscous", 0.73, true},
+	{"quinoa", 0.72, true},
+	{"lentils", 0.81, true},
+	{"breadcrumbs", 0.45, true},
+	{"chocolate chips", 0.72, true},
+	{"raisins", 0.64, true},
+	{"cream cheese", 1.0, true},
+	{"grated parmesan", 0.42, true},
+	{"cheese", 0.45, true},
+	{"honey", 1.42, true},
+	{"maple syrup", 1.32, false},
+	{"golden syrup", 1.4, true},
+	{"syrup", 1.33, false},
+	{"yogurt", 1.03, false},
+	{"yoghurt", 1.03, false},
+	{"cream", 1.0, false},
+	{"milk", 1.03, false},
+	{"olive oil", 0.91, false},
+	{"oil", 0.92, false},
+	{"water", 1.0, false},
+	{"stock", 1.0, false},
+	{"broth", 1.0, false},
+	{"wine", 0.99, false},
+	{"vinegar", 1.01, false},
+	{"soy sauce", 1.15, false},
+	{"juice", 1.04, false},
+}
+
+var nonLetterRegex = regexp.MustCompile(`[^\p{L}]+`)
+
+func findDensity(ingredientName string) (density, bool) {
+	name := " " + strings.TrimSpace(nonLetterRegex.ReplaceAllString(strings.ToLower(ingredientName), " ")) + " "
+	for _, density := range densities {
+		if strings.Contains(name, " "+density.name+" ") || strings.Contains(name, " "+density.name+"s ") {
+			return density, true
+		}
+	}
+	return density{}, false
+}
+
+// Get an ingredient's density in grams per millilitre, found by its name
+func Density(ingredientName string) (float64, bool) {
+	density, ok := findDensity(ingredientName)
+	return density.gramsPerMl, ok
+}
@@ -0,0 +1,222 @@
+package units
+
+import (
+	"math"
+	"strings"
+)
+
+type Kind string
+
+const (
+	KindVolume Kind = "volume"
+	KindMass   Kind = "mass"
+	KindCount  Kind = "count"
+)
+
+type System string
+
+const (
+	SystemMetric   System = "metric"
+	SystemImperial System = "imperial"
+	// units used the same way in both systems, such as spoons
+	SystemNeutral System = ""
+)
+
+type rounding int
+
+const (
+	roundDecimal rounding = iota
+	roundKitchen
+	roundSpoon
+	roundQuarter
+	roundMetric
+	roundCount
+)
+
+// A unit ingredients are measured in
+type Unit struct {
+	// the name units are normalised to
+	Name   string
+	Kind   Kind
+	System System
+	// how many millilitres or grams the unit is, counts are always one
+	Size     float64
+	rounding rounding
+}
+
+type registeredUnit struct {
+	Unit
+	aliases []string
+}
+
+var (
+	Millilitre = Unit{"ml", KindVolume, SystemMetric, 1, roundMetric}
+	Centilitre = Unit{"cl", KindVolume, SystemMetric, 10, roundDecimal}
+	Decilitre  = Unit{"dl", KindVolume, SystemMetric, 100, roundDecimal}
+	Litre      = Unit{"l", KindVolume, SystemMetric, 1000, roundDecimal}
+	Teaspoon   = Unit{"tsp", KindVolume, SystemNeutral, 4.92892, roundSpoon}
+	Tablespoon = Unit{"tbsp", KindVolume, SystemNeutral, 14.7868, roundSpoon}
+	FluidOunce = Unit{"fl oz", KindVolume, SystemImperial, 29.5735, roundQuarter}
+	Cup        = Unit{"cup", KindVolume, SystemImperial, 236.588, roundKitchen}
+	Pint       = Unit{"pint", KindVolume, SystemImperial, 473.176, roundKitchen}
+	Quart      = Unit{"quart", KindVolume, SystemImperial, 946.353, roundKitchen}
+	Gallon     = Unit{"gallon", KindVolume, SystemImperial, 3785.41, roundKitchen}
+	Milligram  = Unit{"mg", KindMass, SystemMetric, 0.001, roundMetric}
+	Gram       = Unit{"g", KindMass, SystemMetric, 1, roundMetric}
+	Kilogram   = Unit{"kg", KindMass, SystemMetric, 1000, roundDecimal}
+	Ounce      = Unit{"oz", KindMass, SystemImperial, 28.3495, roundQuarter}
+	Pound      = Unit{"lb", KindMass, SystemImperial, 453.592, roundQuarter}
+)
+
+func countUnit(name string) Unit {
+	return Unit{name, KindCount, SystemNeutral, 1, roundCount}
+}
+
+var registry = []registeredUnit{
+	{Millilitre, []string{"ml", "mls", "millilitre", "millilitres", "milliliter", "milliliters"}},
+	{Centilitre, []string{"cl", "centilitre", "centilitres", "centiliter", "centiliters"}},
+	{Decilitre, []string{"dl", "decilitre", "decilitres", "deciliter", "deciliters"}},
+	{Litre, []string{"l", "ltr", "litre", "litres", "liter", "liters"}},
+	{Teaspoon, []string{"tsp", "tsps", "teaspoon", "teaspoons"}},
+	{Tablespoon, []string{"tbsp", "tbsps", "tbs", "tbl", "tbls", "tablespoon", "tablespoons"}},
+	{FluidOunce, []string{"fl oz", "fl. oz", "floz", "fluid ounce", "fluid ounces"}},
+	{Cup, []string{"cup", "cups", "c"}},
+	{Pint, []string{"pint", "pints", "pt"}},
+	{Quart, []string{"quart", "quarts", "qt"}},
+	{Gallon, []string{"gallon", "gallons", "gal"}},
+	{Milligram, []string{"mg", "milligram", "milligrams"}},
+	{Gram, []string{"g", "gr", "gram", "grams", "gramme", "grammes"}},
+	{Kilogram, []string{"kg", "kgs", "kilo", "kilos", "kilogram", "kilograms"}},
+	{Ounce, []string{"oz", "ounce", "ounces"}},
+	{Pound, []string{"lb", "lbs", "pound", "pounds"}},
+	{countUnit(""), []string{""}},
+	{countUnit("whole"), []string{"whole"}},
+	{countUnit("each"), []string{"each", "ea"}},
+	{countUnit("piece"), []string{"piece", "pieces", "pc", "pcs"}},
+	{countUnit("clove"), []string{"clove", "cloves"}},
+	{countUnit("can"), []string{"can", "cans", "tin", "tins"}},
+	{countUnit("slice"), []string{"slice", "slices"}},
+	{countUnit("sprig"), []string{"sprig", "sprigs"}},
+	{countUnit("bunch"), []string{"bunch", "bunches"}},
+	{countUnit("handful"), []string{"handful", "handfuls"}},
+	{countUnit("pinch"), []string{"pinch", "pinches"}},
+	{countUnit("dash"), []string{"dash", "dashes"}},
+}
+
+// Cooks tell teaspoons and tablespoons apart by case
+var caseSensitiveAliases = map[string]Unit{
+	"t": Teaspoon,
+	"T": Tablespoon,
+}
+
+var aliases = func() map[string]Unit {
+	aliases := map[string]Unit{}
+	for _, unit := range registry {
+		for _, alias := range unit.aliases {
+			aliases[alias] = unit.Unit
+		}
+	}
+	return aliases
+}()
+
+// Find the unit a free-form unit type refers to, such as "Tbs" or "tablespoon"
+func Lookup(unitType string) (Unit, bool) {
+	unitType = strings.TrimSpace(unitType)
+	if unit, ok := caseSensitiveAliases[unitType]; ok {
+		return unit, true
+	}
+	unitType = strings.Join(strings.Fields(strings.ToLower(strings.TrimSuffix(unitType, "."))), " ")
+	unit, ok := aliases[unitType]
+	return unit, ok
+}
+
+// Get the name a unit type is normalised to, leaving units that aren't known as they are
+func Normalise(unitType string) string {
+	if unit, ok := Lookup(unitType); ok {
+		return unit.Name
+	}
+	return strings.TrimSpace(unitType)
+}
+
+// Convert an amount between units, which must be of the same kind
+func Convert(amount float64, from Unit, to Unit) (float64, bool) {
+	if from.Kind != to.Kind || from.Kind == KindCount {
+		return 0, false
+	}
+	return amount * from.Size / to.Size, true
+}
+
+// Fractions cooks measure with, in eighths and thirds
+var kitchenFractions = []float64{0, 1.0 / 8, 1.0 / 4, 1.0 / 3, 1.0 / 2, 2.0 / 3, 3.0 / 4, 1}
+
+// Spoons and ounces are rarely measured finer than a quarter
+var quarterFractions = []float64{0, 1.0 / 4, 1.0 / 2, 3.0 / 4, 1}
+
+// Small counts can still be halved, like half an onion
+var halfFractions = []float64{0, 1.0 / 2, 1}
+
+// Round to the nearest of the given fractions of a whole
+func roundToFraction(amount float64, fractions []float64) float64 {
+	whole := math.Floor(amount)
+	remainder := amount - whole
+	closest := fractions[0]
+	for _, fraction := range fractions[1:] {
+		if math.Abs(remainder-fraction) < math.Abs(remainder-closest) {
+			closest = fraction
+		}
+	}
+	rounded := whole + closest
+	// never round something away entirely
+	if rounded == 0 {
+		return fractions[1]
+	}
+	return rounded
+}
+
+// Round to a step that suits the size of a metric amount, such as 5g steps above 100g
+func roundToMetricStep(amount float64) float64 {
+	var step float64
+	switch {
+	case amount < 10:
+		step = 0.5
+	case amount < 100:
+		step = 1
+	case amount < 1000:
+		step = 5
+	default:
+		step = 10
+	}
+	return math.Max(math.Round(amount/step)*step, step)
+}
+
+// Round an amount in a way that suits its unit, such as to fractions
+// of a cup or to steps of grams. Units that aren't known are kept to
+// a couple of decimal places
+func Round(amount float64, unitType string) float64 {
+	if amount <= 0 {
+		return 0
+	}
+	unit, ok := Lookup(unitType)
+	if !ok {
+		unit.rounding = roundDecimal
+	}
+	switch unit.rounding {
+	case roundKitchen:
+		return roundToFraction(amount, kitchenFractions)
+	case roundSpoon:
+		if amount < 1.0/4 {
+			return roundToFraction(amount, kitchenFractions)
+		}
+		return roundToFraction(amount, quarterFractions)
+	case roundQuarter:
+		return roundToFraction(amount, quarterFractions)
+	case roundMetric:
+		return roundToMetricStep(amount)
+	case roundCount:
+		if amount < 3 {
+			return roundToFraction(amount, halfFractions)
+		}
+		return math.Round(amount)
+	}
+	return math.Max(math.Round(amount*100)/100, 0.01)
+}
@@ -0,0 +1,121 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		unitType string
+		want     string
+		ok       bool
+	}{
+		{"tablespoons", "tbsp", true},
+		{"Tbs", "tbsp", true},
+		{"TBSP", "tbsp", true},
+		// a capital T is a tablespoon and a small t a teaspoon
+		{"T", "tbsp", true},
+		{"t", "tsp", true},
+		{" t ", "tsp", true},
+		{"TSP", "tsp", true},
+		{"c", "cup", true},
+		{"C", "cup", true},
+		{"fl oz", "fl oz", true},
+		{"Fl.  Oz", "fl oz", true},
+		{"fluid ounces", "fl oz", true},
+		{"lb.", "lb", true},
+		{"lbs", "lb", true},
+		{" Grams ", "g", true},
+		{"kilo", "kg", true},
+		{"cloves", "clove", true},
+		{"tins", "can", true},
+		{"", "", true},
+		{"handful", "handful", true},
+		{"sprinkle", "", false},
+		{"tt", "", false},
+	}
+	for _, test := range tests {
+		unit, ok := Lookup(test.unitType)
+		if unit.Name != test.want || ok != test.ok {
+			t.Errorf("Lookup(%q) = %q, %v, want %q, %v", test.unitType, unit.Name, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestNormalise(t *testing.T) {
+	tests := []struct {
+		unitType string
+		want     string
+	}{
+		{"Cups", "cup"},
+		{"T", "tbsp"},
+		{"  sprinkle ", "sprinkle"},
+		{"Sprinkle", "Sprinkle"},
+	}
+	for _, test := range tests {
+		if got := Normalise(test.unitType); got != test.want {
+			t.Errorf("Normalise(%q) = %q, want %q", test.unitType, got, test.want)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount float64
+		from   Unit
+		to     Unit
+		want   float64
+		ok     bool
+	}{
+		{"cups to millilitres", 1, Cup, Millilitre, 236.588, true},
+		{"kilograms to pounds", 1, Kilogram, Pound, 2.20462, true},
+		{"tablespoons to teaspoons", 1, Tablespoon, Teaspoon, 3, true},
+		{"mass to volume", 1, Gram, Cup, 0, false},
+		{"counts", 1, countUnit("clove"), countUnit("clove"), 0, false},
+	}
+	for _, test := range tests {
+		got, ok := Convert(test.amount, test.from, test.to)
+		if ok != test.ok || math.Abs(got-test.want) > 0.001 {
+			t.Errorf("%s: Convert = %v, %v, want %v, %v", test.name, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   float64
+		unitType string
+		want     float64
+	}{
+		{"a third of a cup", 0.34, "cup", 1.0 / 3},
+		{"two thirds of a cup", 0.7, "cups", 2.0 / 3},
+		{"an eighth of a cup", 0.1, "cup", 1.0 / 8},
+		{"never rounded away", 0.01, "cup", 1.0 / 8},
+		{"cups and a half", 2.45, "cup", 2.5},
+		{"spoons to quarters", 1.6, "tbsp", 1.5},
+		{"small spoons to eighths", 0.13, "tsp", 1.0 / 8},
+		{"ounces to quarters", 1.1, "oz", 1},
+		{"pounds to quarters", 1.4, "lb", 1.5},
+		{"small metric amounts to halves", 7.3, "g", 7.5},
+		{"metric amounts to whole numbers", 57.6, "g", 58},
+		{"metric amounts to fives", 123, "ml", 125},
+		{"large metric amounts to tens", 1234, "g", 1230},
+		{"tiny metric amounts", 0.1, "ml", 0.5},
+		{"larger metric units to decimals", 1.234, "kg", 1.23},
+		{"small counts to halves", 1.3, "", 1.5},
+		{"at least half", 0.1, "clove", 0.5},
+		{"large counts to whole numbers", 4.4, "slices", 4},
+		{"unknown units to decimals", 1.2345, "cube", 1.23},
+		{"tiny unknown amounts", 0.001, "cube", 0.01},
+		{"nothing", 0, "cup", 0},
+		{"negative", -1, "g", 0},
+	}
+	for _, test := range tests {
+		if got := Round(test.amount, test.unitType); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("%s: Round(%v, %q) = %v, want %v", test.name, test.amount, test.unitType, got, test.want)
+		}
+	}
+}